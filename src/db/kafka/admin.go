@@ -0,0 +1,219 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/IBM/sarama"
+)
+
+// TopicSpec declares the desired shape of a topic
+type TopicSpec struct {
+	Name              string
+	Partitions        int32             // Defaults to 1
+	ReplicationFactor int16             // Defaults to 3; capped at the number of brokers
+	Configs           map[string]string // e.g. retention.ms, cleanup.policy=compact
+}
+
+// TopicDescription is a topic's partition layout and effective configuration
+type TopicDescription struct {
+	Name              string
+	Partitions        []*sarama.PartitionMetadata
+	ReplicationFactor int
+	Configs           map[string]string
+}
+
+// EnsureTopic creates the topic when it is missing. For an existing topic it
+// raises the partition count to spec.Partitions and applies spec.Configs;
+// partitions are never decreased and replication is not changed.
+func (c *Client) EnsureTopic(spec TopicSpec) error {
+	if spec.Name == "" {
+		return errors.New("topic name is required")
+	}
+	if spec.Partitions <= 0 {
+		spec.Partitions = 1
+	}
+
+	replication, err := c.replicationFactor(spec.ReplicationFactor)
+	if err != nil {
+		return err
+	}
+
+	admin, err := c.conn.ClusterAdmin()
+	if err != nil {
+		return err
+	}
+
+	err = admin.CreateTopic(spec.Name, &sarama.TopicDetail{
+		NumPartitions:     spec.Partitions,
+		ReplicationFactor: replication,
+		ConfigEntries:     configEntries(spec.Configs),
+	}, false)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, sarama.ErrTopicAlreadyExists) {
+		return fmt.Errorf("failed to create topic %s: %w", spec.Name, err)
+	}
+
+	desc, err := c.DescribeTopic(spec.Name)
+	if err != nil {
+		return err
+	}
+	if int32(len(desc.Partitions)) < spec.Partitions {
+		if err := c.IncreasePartitions(spec.Name, spec.Partitions); err != nil {
+			return err
+		}
+	}
+	return c.alterTopicConfigs(spec.Name, spec.Configs)
+}
+
+// EnsureTopics applies EnsureTopic to every spec, stopping at the first failure
+func (c *Client) EnsureTopics(specs ...TopicSpec) error {
+	for _, spec := range specs {
+		if err := c.EnsureTopic(spec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListTopics returns every topic in the cluster keyed by name
+func (c *Client) ListTopics() (map[string]sarama.TopicDetail, error) {
+	admin, err := c.conn.ClusterAdmin()
+	if err != nil {
+		return nil, err
+	}
+
+	topics, err := admin.ListTopics()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list topics: %w", err)
+	}
+	return topics, nil
+}
+
+// DescribeTopic returns the partitions and non-default configs of a topic
+func (c *Client) DescribeTopic(name string) (*TopicDescription, error) {
+	admin, err := c.conn.ClusterAdmin()
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := admin.DescribeTopics([]string{name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe topic %s: %w", name, err)
+	}
+	if len(metadata) == 0 {
+		return nil, fmt.Errorf("failed to describe topic %s: %w", name, sarama.ErrUnknownTopicOrPartition)
+	}
+	if metadata[0].Err != sarama.ErrNoError {
+		return nil, fmt.Errorf("failed to describe topic %s: %w", name, metadata[0].Err)
+	}
+
+	entries, err := admin.DescribeConfig(sarama.ConfigResource{
+		Type: sarama.TopicResource,
+		Name: name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe configs of topic %s: %w", name, err)
+	}
+
+	desc := &TopicDescription{
+		Name:       name,
+		Partitions: metadata[0].Partitions,
+		Configs:    make(map[string]string),
+	}
+	if len(desc.Partitions) > 0 {
+		desc.ReplicationFactor = len(desc.Partitions[0].Replicas)
+	}
+	for _, e := range entries {
+		if !e.Default {
+			desc.Configs[e.Name] = e.Value
+		}
+	}
+	return desc, nil
+}
+
+// IncreasePartitions grows a topic to count partitions
+func (c *Client) IncreasePartitions(name string, count int32) error {
+	admin, err := c.conn.ClusterAdmin()
+	if err != nil {
+		return err
+	}
+
+	if err := admin.CreatePartitions(name, count, nil, false); err != nil {
+		return fmt.Errorf("failed to increase partitions of %s to %d: %w", name, count, err)
+	}
+	return nil
+}
+
+func (c *Client) alterTopicConfigs(name string, configs map[string]string) error {
+	if len(configs) == 0 {
+		return nil
+	}
+
+	admin, err := c.conn.ClusterAdmin()
+	if err != nil {
+		return err
+	}
+
+	entries := make(map[string]sarama.IncrementalAlterConfigsEntry, len(configs))
+	for k, v := range configs {
+		value := v
+		entries[k] = sarama.IncrementalAlterConfigsEntry{
+			Operation: sarama.IncrementalAlterConfigsOperationSet,
+			Value:     &value,
+		}
+	}
+
+	if err := admin.IncrementalAlterConfig(sarama.TopicResource, name, entries, false); err != nil {
+		return fmt.Errorf("failed to update configs of topic %s: %w", name, err)
+	}
+	return nil
+}
+
+// replicationFactor caps the requested replication at the number of live brokers
+func (c *Client) replicationFactor(requested int16) (int16, error) {
+	if requested <= 0 {
+		requested = 3
+	}
+
+	admin, err := c.conn.ClusterAdmin()
+	if err != nil {
+		return 0, err
+	}
+
+	brokers, _, err := admin.DescribeCluster()
+	if err != nil {
+		return 0, fmt.Errorf("failed to describe cluster: %w", err)
+	}
+	if n := int16(len(brokers)); n > 0 && requested > n {
+		return n, nil
+	}
+	return requested, nil
+}
+
+func configEntries(configs map[string]string) map[string]*string {
+	if len(configs) == 0 {
+		return nil
+	}
+	entries := make(map[string]*string, len(configs))
+	for k, v := range configs {
+		value := v
+		entries[k] = &value
+	}
+	return entries
+}
+
+// dlqTopicSpec builds the DLQ topic declaration from the client config
+func (c *Client) dlqTopicSpec() TopicSpec {
+	spec := TopicSpec{
+		Name:              c.config.DeadLetterTopic,
+		Partitions:        int32(c.config.DeadLetterPartitions),
+		ReplicationFactor: int16(c.config.DeadLetterReplicationFactor),
+	}
+	if c.config.DeadLetterRetentionMs > 0 {
+		spec.Configs = map[string]string{"retention.ms": fmt.Sprint(c.config.DeadLetterRetentionMs)}
+	}
+	return spec
+}
@@ -0,0 +1,44 @@
+package kafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// API is the publishing, consuming, topic and DLQ surface of Client. Depend
+// on it instead of *Client to swap in kafkatest.Client in unit tests.
+type API interface {
+	Publish(ctx context.Context, topic string, key, value []byte, middlewares ...Middleware) error
+	PublishWithHeaders(ctx context.Context, topic string, key, value []byte, headers []sarama.RecordHeader, middlewares ...Middleware) error
+	PublishOnce(ctx context.Context, topic string, key, value []byte, headers []sarama.RecordHeader) error
+	Subscribe(ctx context.Context, topics []string, handler func(context.Context, *sarama.ConsumerMessage) error, middlewares ...Middleware) error
+	SubscribeMessages(ctx context.Context, topics []string, handler Handler, middlewares ...ConsumerMiddleware) error
+	SubscribeBatch(ctx context.Context, topics []string, size int, maxWait time.Duration, handler BatchHandler) error
+	EnsureTopic(spec TopicSpec) error
+	EnsureTopics(specs ...TopicSpec) error
+	ListTopics() (map[string]sarama.TopicDetail, error)
+	DescribeTopic(name string) (*TopicDescription, error)
+	Transaction(ctx context.Context, fn func(tx *Tx) error) error
+	SubscribeTransactional(ctx context.Context, topics []string, handler TransformHandler) error
+	NewAsyncPublisher(callbacks AsyncCallbacks) (AsyncAPI, error)
+	ResetOffsets(groupID, topic string, reset OffsetReset) error
+	ListDeadLetters(ctx context.Context, filter DeadLetterFilter) ([]DeadLetter, error)
+	Redrive(ctx context.Context, letters []DeadLetter, ratePerSecond int) (int, error)
+	RedriveDeadLetters(ctx context.Context, filter DeadLetterFilter, ratePerSecond int) (int, error)
+	DeadLetter(ctx context.Context, msg *sarama.ConsumerMessage, attempts int, cause error, headers ...sarama.RecordHeader) error
+	Close() error
+}
+
+// AsyncAPI is the surface of AsyncPublisher
+type AsyncAPI interface {
+	Publish(ctx context.Context, topic string, key, value []byte, middlewares ...Middleware) error
+	PublishWithHeaders(ctx context.Context, topic string, key, value []byte, headers []sarama.RecordHeader, middlewares ...Middleware) error
+	Close() error
+}
+
+var (
+	_ API      = (*Client)(nil)
+	_ AsyncAPI = (*AsyncPublisher)(nil)
+)
@@ -0,0 +1,198 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+var ErrPublisherClosed = errors.New("async publisher is closed")
+
+// AsyncCallbacks receive the outcome of every message sent through an AsyncPublisher.
+// Both callbacks run on the publisher's delivery goroutines and must not block.
+type AsyncCallbacks struct {
+	OnSuccess func(*sarama.ProducerMessage)
+	OnError   func(*sarama.ProducerMessage, error)
+}
+
+// AsyncPublisher batches messages through a sarama.AsyncProducer.
+// Messages that fail delivery are forwarded to the client's DLQ.
+type AsyncPublisher struct {
+	client    *Client
+	conn      sarama.Client // Owned by the publisher, unlike the Client's shared one
+	producer  sarama.AsyncProducer
+	callbacks AsyncCallbacks
+
+	mu        sync.RWMutex
+	closed    bool
+	wg        sync.WaitGroup
+	closeErrs []error // Delivery failures while Close flushes; read after wg.Wait
+}
+
+// NewAsyncPublisher creates an async producer using the client's batching and compression settings.
+// It opens its own broker connections: sarama takes producer settings from the
+// client a producer is built on, and the shared client carries the sync
+// producer's flush, compression and transactional ID settings.
+func (c *Client) NewAsyncPublisher(callbacks AsyncCallbacks) (AsyncAPI, error) {
+	saramaConfig, err := newSaramaConfig(c.config)
+	if err != nil {
+		return nil, err
+	}
+
+	compression, err := parseCompression(c.config.Compression)
+	if err != nil {
+		return nil, err
+	}
+
+	// Async publishing is never transactional
+	saramaConfig.Producer.Transaction.ID = ""
+	saramaConfig.Producer.Return.Successes = true
+	saramaConfig.Producer.Return.Errors = true
+	saramaConfig.Producer.Flush.Messages = c.config.AsyncFlushMessages
+	saramaConfig.Producer.Flush.Frequency = time.Duration(c.config.AsyncFlushFrequencyMs) * time.Millisecond
+	saramaConfig.Producer.Flush.Bytes = c.config.AsyncFlushBytes
+	saramaConfig.Producer.Compression = compression
+
+	conn, err := sarama.NewClient(parseBrokers(c.config.BootstrapServers), saramaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create async producer client: %w", err)
+	}
+	producer, err := sarama.NewAsyncProducerFromClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create async producer: %w", err)
+	}
+
+	p := &AsyncPublisher{
+		client:    c,
+		conn:      conn,
+		producer:  producer,
+		callbacks: callbacks,
+	}
+
+	p.wg.Add(2)
+	go p.handleSuccesses()
+	go p.handleErrors()
+
+	return p, nil
+}
+
+// Publish queues a message for delivery; it only blocks while the producer's input buffer is full
+func (p *AsyncPublisher) Publish(ctx context.Context, topic string, key, value []byte, middlewares ...Middleware) error {
+	return p.PublishWithHeaders(ctx, topic, key, value, nil, middlewares...)
+}
+
+// PublishWithHeaders is Publish with record headers attached to the message
+func (p *AsyncPublisher) PublishWithHeaders(ctx context.Context, topic string, key, value []byte, headers []sarama.RecordHeader, middlewares ...Middleware) error {
+	for _, mw := range middlewares {
+		if err := mw(ctx, topic, value); err != nil {
+			return fmt.Errorf("middleware failed: %w", err)
+		}
+	}
+
+	msg := &sarama.ProducerMessage{
+		Topic:   topic,
+		Key:     sarama.ByteEncoder(key),
+		Value:   sarama.ByteEncoder(value),
+		Headers: headers,
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrPublisherClosed
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case p.producer.Input() <- msg:
+		return nil
+	}
+}
+
+// Close flushes buffered messages, waits for their delivery callbacks and
+// closes the publisher's connections. It returns the delivery failures seen
+// while flushing and any error closing the client, joined.
+func (p *AsyncPublisher) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	p.producer.AsyncClose()
+	p.wg.Wait()
+
+	errs := p.closeErrs
+	if err := p.conn.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to close async producer client: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+func (p *AsyncPublisher) handleSuccesses() {
+	defer p.wg.Done()
+	for msg := range p.producer.Successes() {
+		if p.callbacks.OnSuccess != nil {
+			p.callbacks.OnSuccess(msg)
+		}
+	}
+}
+
+func (p *AsyncPublisher) handleErrors() {
+	defer p.wg.Done()
+	for perr := range p.producer.Errors() {
+		msg := perr.Msg
+		log.Printf("Async publish to %s failed: %v", msg.Topic, perr.Err)
+
+		if p.callbacks.OnError != nil {
+			p.callbacks.OnError(msg, perr.Err)
+		}
+
+		p.mu.RLock()
+		if p.closed {
+			p.closeErrs = append(p.closeErrs, fmt.Errorf("failed to deliver message to %s: %w", msg.Topic, perr.Err))
+		}
+		p.mu.RUnlock()
+
+		key, _ := encodeOrNil(msg.Key)
+		value, _ := encodeOrNil(msg.Value)
+		err := p.client.sendToDLQ(context.Background(), msg.Topic, key, value, DeliveryFailureHeaders(perr.Err, msg.Headers)...)
+		if err != nil {
+			log.Printf("Async publish DLQ error for topic %s: %v", msg.Topic, err)
+		}
+	}
+}
+
+func encodeOrNil(e sarama.Encoder) ([]byte, error) {
+	if e == nil {
+		return nil, nil
+	}
+	return e.Encode()
+}
+
+func parseCompression(codec string) (sarama.CompressionCodec, error) {
+	switch strings.ToLower(codec) {
+	case "", "none":
+		return sarama.CompressionNone, nil
+	case "gzip":
+		return sarama.CompressionGZIP, nil
+	case "snappy":
+		return sarama.CompressionSnappy, nil
+	case "lz4":
+		return sarama.CompressionLZ4, nil
+	case "zstd":
+		return sarama.CompressionZSTD, nil
+	default:
+		return sarama.CompressionNone, fmt.Errorf("unsupported compression codec: %s", codec)
+	}
+}
@@ -0,0 +1,111 @@
+package kafka
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// BatchHandler processes a batch of messages from a single partition
+type BatchHandler func(ctx context.Context, msgs []*Message) error
+
+// SubscribeBatch delivers messages in per-partition batches of up to size
+// messages, flushing early after maxWait. Offsets are committed once per
+// successful batch; a batch that exhausts its retries is dead-lettered
+// message by message and then committed.
+func (c *Client) SubscribeBatch(ctx context.Context, topics []string, size int, maxWait time.Duration, handler BatchHandler) error {
+	if size <= 0 {
+		size = 100
+	}
+	if maxWait <= 0 {
+		maxWait = time.Second
+	}
+	return c.consume(ctx, topics, &batchConsumerGroupHandler{
+		client:  c,
+		handler: handler,
+		size:    size,
+		maxWait: maxWait,
+	})
+}
+
+type batchConsumerGroupHandler struct {
+	client  *Client
+	handler BatchHandler
+	size    int
+	maxWait time.Duration
+}
+
+func (h *batchConsumerGroupHandler) Setup(_ sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (h *batchConsumerGroupHandler) Cleanup(_ sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (h *batchConsumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	ctx := session.Context()
+	batch := make([]*Message, 0, h.size)
+	timer := time.NewTimer(h.maxWait)
+	defer timer.Stop()
+
+	flush := func() (bool, error) {
+		if len(batch) == 0 {
+			return true, nil
+		}
+		ok, err := h.flush(ctx, session, batch)
+		batch = batch[:0]
+		return ok, err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				_, err := flush()
+				return err
+			}
+			batch = append(batch, NewMessage(msg))
+			if len(batch) < h.size {
+				continue
+			}
+		case <-timer.C:
+		}
+
+		ok, err := flush()
+		if err != nil || !ok {
+			return err
+		}
+		timer.Reset(h.maxWait)
+	}
+}
+
+// flush runs the handler over batch and commits the last offset.
+// It reports false when the session ended before the batch was settled.
+func (h *batchConsumerGroupHandler) flush(ctx context.Context, session sarama.ConsumerGroupSession, batch []*Message) (bool, error) {
+	c := h.client
+	last := batch[len(batch)-1].Raw
+
+	attempts, err := c.retryMessage(ctx, batch[0].Raw, func() error {
+		return h.handler(ctx, batch)
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, nil
+		}
+		for _, m := range batch {
+			if dlqErr := c.sendToDLQ(ctx, m.Topic, m.Key, m.Value, FailureHeaders(m.Raw, attempts, err)...); dlqErr != nil {
+				log.Printf("Failed to dead-letter message %s/%d/%d: %v", m.Topic, m.Partition, m.Offset, dlqErr)
+				return false, dlqErr
+			}
+		}
+	}
+
+	session.MarkMessage(last, "")
+	session.Commit()
+	return true, nil
+}
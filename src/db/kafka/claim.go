@@ -0,0 +1,145 @@
+package kafka
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+
+	"github.com/IBM/sarama"
+)
+
+// offsetTracker marks offsets only once every earlier message of the claim has completed
+type offsetTracker struct {
+	mu      sync.Mutex
+	session sarama.ConsumerGroupSession
+	pending []*trackedMessage
+}
+
+type trackedMessage struct {
+	msg  *sarama.ConsumerMessage
+	done bool
+	slot sync.Once // Releases the message's in-flight slot exactly once
+}
+
+func (t *offsetTracker) add(msg *sarama.ConsumerMessage) *trackedMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tm := &trackedMessage{msg: msg}
+	t.pending = append(t.pending, tm)
+	return tm
+}
+
+// complete records tm as finished and marks the highest contiguous finished offset
+func (t *offsetTracker) complete(tm *trackedMessage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if tm.done {
+		return
+	}
+	tm.done = true
+	var last *sarama.ConsumerMessage
+	for len(t.pending) > 0 && t.pending[0].done {
+		last = t.pending[0].msg
+		t.pending = t.pending[1:]
+	}
+	if last != nil {
+		t.session.MarkMessage(last, "")
+	}
+}
+
+// consumeParallel fans a claim out to ClaimWorkers goroutines keyed by message key.
+// Messages sharing a key are handled in order by the same worker, at most
+// ClaimMaxInFlight messages are unfinished at a time, and offsets are marked
+// up to the lowest contiguous completed offset. In manual commit mode a
+// message only counts as completed once its handler acknowledges it, and it
+// keeps its in-flight slot until then, so unacknowledged messages throttle
+// the claim instead of piling up behind an offset that can never be marked.
+func (h *ConsumerGroupHandler) consumeParallel(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	cfg := h.client.config
+	ctx, cancel := context.WithCancel(session.Context())
+	defer cancel()
+
+	tracker := &offsetTracker{session: session}
+	inFlight := make(chan struct{}, cfg.ClaimMaxInFlight)
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		fatalErr error
+	)
+
+	queues := make([]chan *trackedMessage, cfg.ClaimWorkers)
+	for i := range queues {
+		queues[i] = make(chan *trackedMessage, cfg.ClaimMaxInFlight)
+		wg.Add(1)
+		go func(queue <-chan *trackedMessage) {
+			defer wg.Done()
+			for tm := range queue {
+				tm := tm
+				release := func() { tm.slot.Do(func() { <-inFlight }) }
+				if ctx.Err() != nil {
+					release()
+					continue
+				}
+
+				ack := func() {
+					tracker.complete(tm)
+					if cfg.ManualCommit {
+						session.Commit()
+					}
+					release()
+				}
+
+				outcome, err := h.handleMessage(ctx, tm.msg, ack)
+				switch {
+				case err != nil:
+					errOnce.Do(func() {
+						fatalErr = err
+						cancel()
+					})
+					release()
+				case outcome == outcomeDeadLettered || (outcome == outcomeProcessed && !cfg.ManualCommit):
+					ack()
+				case outcome == outcomeAbandoned:
+					release()
+				}
+			}
+		}(queues[i])
+	}
+
+dispatch:
+	for {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				break dispatch
+			}
+			select {
+			case <-ctx.Done():
+				break dispatch
+			case inFlight <- struct{}{}:
+			}
+			queues[workerFor(msg, len(queues))] <- tracker.add(msg)
+		}
+	}
+
+	for _, queue := range queues {
+		close(queue)
+	}
+	wg.Wait()
+	return fatalErr
+}
+
+// workerFor routes keyed messages by hash so equal keys share a worker
+func workerFor(msg *sarama.ConsumerMessage, workers int) int {
+	if len(msg.Key) == 0 {
+		return int(msg.Offset % int64(workers))
+	}
+	hash := fnv.New32a()
+	hash.Write(msg.Key)
+	return int(hash.Sum32() % uint32(workers))
+}
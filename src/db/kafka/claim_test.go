@@ -0,0 +1,89 @@
+package kafka
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+// markingSession records the offsets marked through MarkMessage
+type markingSession struct {
+	sarama.ConsumerGroupSession
+	marked []int64
+}
+
+func (s *markingSession) MarkMessage(msg *sarama.ConsumerMessage, _ string) {
+	s.marked = append(s.marked, msg.Offset)
+}
+
+func TestOffsetTrackerComplete(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages int
+		order    []int   // Indexes of messages in completion order
+		want     []int64 // Offsets marked, in order
+		pending  int
+	}{
+		{
+			name:     "in order",
+			messages: 3,
+			order:    []int{0, 1, 2},
+			want:     []int64{10, 11, 12},
+		},
+		{
+			name:     "reverse order marks once at the end",
+			messages: 3,
+			order:    []int{2, 1, 0},
+			want:     []int64{12},
+		},
+		{
+			name:     "gap holds later offsets",
+			messages: 4,
+			order:    []int{1, 3, 0},
+			want:     []int64{11},
+			pending:  2,
+		},
+		{
+			name:     "gap filled releases contiguous run",
+			messages: 4,
+			order:    []int{1, 3, 0, 2},
+			want:     []int64{11, 13},
+		},
+		{
+			name:     "nothing marked while first is unfinished",
+			messages: 3,
+			order:    []int{1, 2},
+			want:     nil,
+			pending:  3,
+		},
+		{
+			name:     "duplicate completion is ignored",
+			messages: 2,
+			order:    []int{0, 0, 1},
+			want:     []int64{10, 11},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := &markingSession{}
+			tracker := &offsetTracker{session: session}
+
+			tracked := make([]*trackedMessage, tt.messages)
+			for i := range tracked {
+				tracked[i] = tracker.add(&sarama.ConsumerMessage{Offset: int64(10 + i)})
+			}
+			for _, i := range tt.order {
+				tracker.complete(tracked[i])
+			}
+
+			if !reflect.DeepEqual(session.marked, tt.want) {
+				t.Errorf("marked %v, want %v", session.marked, tt.want)
+			}
+			if len(tracker.pending) != tt.pending {
+				t.Errorf("pending %d, want %d", len(tracker.pending), tt.pending)
+			}
+		})
+	}
+}
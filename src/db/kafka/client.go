@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/IBM/sarama"
@@ -12,50 +14,114 @@ import (
 type Client struct {
 	conn   *KafkaConn
 	config *KafkaConfig
+	txnMu  sync.Mutex // Serializes transactions on the shared producer
 }
 
 type Middleware func(context.Context, string, []byte) error
 
+// NewClientWithConfig connects and ensures the DLQ and configured topics exist.
+// The producer and consumer group are created on first use.
 func NewClientWithConfig(cfg KafkaConfig) (*Client, error) {
-	conn, err := ConnectFromEnv(cfg)
+	client, err := newClient(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	client := &Client{
-		conn:   conn,
-		config: LoadKafkaConfig(cfg),
-	}
-
 	if err := client.setupDLQ(); err != nil {
-		conn.Close()
+		client.Close()
 		return nil, err
 	}
 
 	return client, nil
 }
 
-func (c *Client) setupDLQ() error {
-	topic := c.config.DeadLetterTopic
-	err := c.conn.Admin.CreateTopic(topic, &sarama.TopicDetail{
-		NumPartitions:     2,
-		ReplicationFactor: 2,
-	}, false)
+// NewProducer creates a client for services that only publish
+func NewProducer(cfg KafkaConfig) (*Client, error) {
+	return newClientWithRole(cfg, func(conn *KafkaConn) error {
+		_, err := conn.SyncProducer()
+		return err
+	})
+}
+
+// NewConsumer creates a client for services that subscribe; the producer used
+// for dead-lettering is still created lazily on the first failure
+func NewConsumer(cfg KafkaConfig) (*Client, error) {
+	return newClientWithRole(cfg, func(conn *KafkaConn) error {
+		_, err := conn.ConsumerGroup()
+		return err
+	})
+}
+
+// NewAdmin creates a client for topic and offset administration
+func NewAdmin(cfg KafkaConfig) (*Client, error) {
+	return newClientWithRole(cfg, func(conn *KafkaConn) error {
+		_, err := conn.ClusterAdmin()
+		return err
+	})
+}
+
+func newClient(cfg KafkaConfig) (*Client, error) {
+	config := LoadKafkaConfig(cfg)
+	conn, err := connect(config)
 	if err != nil {
-		if err == sarama.ErrTopicAlreadyExists {
-			return nil
-		}
-		return fmt.Errorf("failed to create DLQ topic %s: %w", topic, err)
+		return nil, err
 	}
-	return nil
+
+	return &Client{
+		conn:   conn,
+		config: config,
+	}, nil
+}
+
+// newClientWithRole connects and eagerly creates one role so misconfiguration fails fast
+func newClientWithRole(cfg KafkaConfig, init func(*KafkaConn) error) (*Client, error) {
+	client, err := newClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := init(client.conn); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+func (c *Client) setupDLQ() error {
+	return c.EnsureTopics(append([]TopicSpec{c.dlqTopicSpec()}, c.config.Topics...)...)
 }
 
 // Publish sends a message to the specified topic with retry logic
 func (c *Client) Publish(ctx context.Context, topic string, key, value []byte, middlewares ...Middleware) error {
+	return c.publish(ctx, topic, key, value, nil, middlewares...)
+}
+
+// PublishWithHeaders is Publish with record headers attached to the message
+func (c *Client) PublishWithHeaders(ctx context.Context, topic string, key, value []byte, headers []sarama.RecordHeader, middlewares ...Middleware) error {
+	return c.publish(ctx, topic, key, value, headers, middlewares...)
+}
+
+// PublishOnce sends a message in a single attempt without the retry loop or
+// the DLQ fallback, for callers such as the outbox relay that schedule their
+// own retries. The producer's own retries (MaxRetries) still apply.
+func (c *Client) PublishOnce(ctx context.Context, topic string, key, value []byte, headers []sarama.RecordHeader) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.send(&sarama.ProducerMessage{
+		Topic:   topic,
+		Key:     sarama.ByteEncoder(key),
+		Value:   sarama.ByteEncoder(value),
+		Headers: headers,
+	})
+}
+
+func (c *Client) publish(ctx context.Context, topic string, key, value []byte, headers []sarama.RecordHeader, middlewares ...Middleware) error {
 	msg := &sarama.ProducerMessage{
-		Topic: topic,
-		Key:   sarama.ByteEncoder(key),
-		Value: sarama.ByteEncoder(value),
+		Topic:   topic,
+		Key:     sarama.ByteEncoder(key),
+		Value:   sarama.ByteEncoder(value),
+		Headers: headers,
 	}
 
 	for _, mw := range middlewares {
@@ -70,7 +136,7 @@ func (c *Client) Publish(ctx context.Context, topic string, key, value []byte, m
 		case <-ctx.Done():
 			return ctx.Err()
 		default:
-			_, _, err := c.conn.Producer.SendMessage(msg)
+			err := c.send(msg)
 			if err == nil {
 				return nil
 			}
@@ -80,37 +146,76 @@ func (c *Client) Publish(ctx context.Context, topic string, key, value []byte, m
 		}
 	}
 
-	if dlqErr := c.sendToDLQ(ctx, topic, key, value); dlqErr != nil {
+	if dlqErr := c.sendToDLQ(ctx, topic, key, value, headers...); dlqErr != nil {
 		return fmt.Errorf("max retries reached, last error: %v, DLQ error: %v", lastErr, dlqErr)
 	}
 	return fmt.Errorf("max retries reached, last error: %v", lastErr)
 
 }
 
+// send produces a single message, wrapping it in its own transaction when the producer is transactional
+func (c *Client) send(msg *sarama.ProducerMessage) error {
+	if !c.transactional() {
+		producer, err := c.conn.SyncProducer()
+		if err != nil {
+			return err
+		}
+		_, _, err = producer.SendMessage(msg)
+		return err
+	}
+
+	c.txnMu.Lock()
+	defer c.txnMu.Unlock()
+	return c.runTxn(context.Background(), func(tx *Tx) error {
+		return tx.send(msg)
+	})
+}
+
+// DeadLetter sends a consumed message to the DLQ with the same failure headers
+// the consumer adds after attempts, followed by headers
+func (c *Client) DeadLetter(ctx context.Context, msg *sarama.ConsumerMessage, attempts int, cause error, headers ...sarama.RecordHeader) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.sendToDLQ(ctx, msg.Topic, msg.Key, msg.Value, append(FailureHeaders(msg, attempts, cause), headers...)...)
+}
+
 // sendToDLQ sends a failed message to the dead letter queue
-func (c *Client) sendToDLQ(ctx context.Context, topic string, key, value []byte) error {
-	dlqMsg := &sarama.ProducerMessage{
-		Topic: c.config.DeadLetterTopic,
-		Key:   sarama.ByteEncoder(key),
-		Value: sarama.ByteEncoder(value),
-		Headers: []sarama.RecordHeader{
-			{Key: []byte("original-topic"), Value: []byte(topic)},
-			{Key: []byte("timestamp"), Value: []byte(time.Now().UTC().String())},
-		},
-	}
-
-	_, _, err := c.conn.Producer.SendMessage(dlqMsg)
-	if err != nil {
+func (c *Client) sendToDLQ(ctx context.Context, topic string, key, value []byte, headers ...sarama.RecordHeader) error {
+	if err := c.send(c.dlqMessage(topic, key, value, headers...)); err != nil {
 		return fmt.Errorf("failed to send message to DLQ: %w", err)
 	}
 	return nil
 }
 
+func (c *Client) dlqMessage(topic string, key, value []byte, headers ...sarama.RecordHeader) *sarama.ProducerMessage {
+	return &sarama.ProducerMessage{
+		Topic:   c.config.DeadLetterTopic,
+		Key:     sarama.ByteEncoder(key),
+		Value:   sarama.ByteEncoder(value),
+		Headers: DeadLetterHeaders(topic, headers...),
+	}
+}
+
+// DeadLetterHeaders returns the headers of a message dead-lettered from
+// topic: its origin and the time, followed by headers
+func DeadLetterHeaders(topic string, headers ...sarama.RecordHeader) []sarama.RecordHeader {
+	return append([]sarama.RecordHeader{
+		{Key: []byte("original-topic"), Value: []byte(topic)},
+		{Key: []byte("timestamp"), Value: []byte(time.Now().UTC().String())},
+	}, headers...)
+}
+
+// DeliveryFailureHeaders describes a message the producer failed to deliver
+// with cause, followed by the message's own headers
+func DeliveryFailureHeaders(cause error, headers []sarama.RecordHeader) []sarama.RecordHeader {
+	return append([]sarama.RecordHeader{{Key: []byte("error"), Value: []byte(cause.Error())}}, headers...)
+}
+
 // ConsumerGroupHandler implements sarama.ConsumerGroupHandler for message processing
 type ConsumerGroupHandler struct {
-	handler     func(context.Context, *sarama.ConsumerMessage) error
-	middlewares []Middleware
-	topic       string
+	client  *Client
+	handler Handler // Handler with its middleware chain applied
 }
 
 func (h *ConsumerGroupHandler) Setup(_ sarama.ConsumerGroupSession) error {
@@ -122,38 +227,166 @@ func (h *ConsumerGroupHandler) Cleanup(_ sarama.ConsumerGroupSession) error {
 }
 
 func (h *ConsumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	if h.client.config.ClaimWorkers > 1 {
+		return h.consumeParallel(session, claim)
+	}
+
 	ctx := session.Context()
+	manual := h.client.config.ManualCommit
 	for msg := range claim.Messages() {
-		for _, mw := range h.middlewares {
-			if err := mw(ctx, h.topic, msg.Value); err != nil {
-				log.Printf("Middleware error for message: %v", err)
-				return err
+		ack := func() {
+			session.MarkMessage(msg, "")
+			if manual {
+				session.Commit()
 			}
 		}
 
-		if err := h.handler(ctx, msg); err != nil {
-			log.Printf("Handler error for message: %v", err)
+		outcome, err := h.handleMessage(ctx, msg, ack)
+		if err != nil {
 			return err
 		}
-		session.MarkMessage(msg, "")
+		switch {
+		case outcome == outcomeAbandoned:
+			return nil
+		case outcome == outcomeDeadLettered || !manual:
+			ack()
+		}
 	}
 	return nil
 }
 
+type messageOutcome int
+
+const (
+	outcomeProcessed    messageOutcome = iota // Handler succeeded
+	outcomeDeadLettered                       // Retries ran out and the message is in the DLQ
+	outcomeAbandoned                          // Session ended; the message must be redelivered
+)
+
+// handleMessage processes msg with retries and dead-letters it when they run out.
+// An error means the message could not be dead-lettered and the claim must stop.
+func (h *ConsumerGroupHandler) handleMessage(ctx context.Context, msg *sarama.ConsumerMessage, ack func()) (messageOutcome, error) {
+	attempts, err := h.processWithRetry(ctx, msg, ack)
+	if err == nil {
+		return outcomeProcessed, nil
+	}
+	if ctx.Err() != nil {
+		// Session is ending; leave the message unmarked so it is redelivered
+		return outcomeAbandoned, nil
+	}
+	if dlqErr := h.deadLetter(ctx, msg, attempts, err); dlqErr != nil {
+		log.Printf("Failed to dead-letter message %s/%d/%d: %v", msg.Topic, msg.Partition, msg.Offset, dlqErr)
+		return outcomeAbandoned, dlqErr
+	}
+	return outcomeDeadLettered, nil
+}
+
+// processWithRetry runs the middlewares and handler, retrying with exponential backoff
+func (h *ConsumerGroupHandler) processWithRetry(ctx context.Context, msg *sarama.ConsumerMessage, ack func()) (int, error) {
+	return h.client.retryMessage(ctx, msg, func() error {
+		return h.process(ctx, msg, ack)
+	})
+}
+
+// retryMessage calls fn until it succeeds or ConsumerMaxRetries attempts are used,
+// returning the number of attempts made
+func (c *Client) retryMessage(ctx context.Context, msg *sarama.ConsumerMessage, fn func() error) (int, error) {
+	backoff := time.Duration(c.config.ConsumerRetryBackoffMs) * time.Millisecond
+	maxBackoff := time.Duration(c.config.ConsumerMaxBackoffMs) * time.Millisecond
+
+	var lastErr error
+	for attempt := 1; attempt <= c.config.ConsumerMaxRetries; attempt++ {
+		lastErr = fn()
+		if lastErr == nil {
+			return attempt, nil
+		}
+		log.Printf("Handler error for message %s/%d/%d (attempt %d/%d): %v",
+			msg.Topic, msg.Partition, msg.Offset, attempt, c.config.ConsumerMaxRetries, lastErr)
+
+		if attempt == c.config.ConsumerMaxRetries {
+			return attempt, lastErr
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempt, ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+	return c.config.ConsumerMaxRetries, lastErr
+}
+
+func (h *ConsumerGroupHandler) process(ctx context.Context, msg *sarama.ConsumerMessage, ack func()) error {
+	m := NewMessage(msg)
+	m.ack = ack
+	return h.handler(ctx, m)
+}
+
+// deadLetter forwards a message that exhausted its retries to the DLQ
+func (h *ConsumerGroupHandler) deadLetter(ctx context.Context, msg *sarama.ConsumerMessage, attempts int, cause error) error {
+	return h.client.sendToDLQ(ctx, msg.Topic, msg.Key, msg.Value, FailureHeaders(msg, attempts, cause)...)
+}
+
+// FailureHeaders describes where a consumed message came from and why it
+// failed after attempts, followed by the message's own headers
+func FailureHeaders(msg *sarama.ConsumerMessage, attempts int, cause error) []sarama.RecordHeader {
+	headers := []sarama.RecordHeader{
+		{Key: []byte("original-partition"), Value: []byte(strconv.Itoa(int(msg.Partition)))},
+		{Key: []byte("original-offset"), Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		{Key: []byte("error"), Value: []byte(cause.Error())},
+		{Key: []byte("attempts"), Value: []byte(strconv.Itoa(attempts))},
+	}
+	for _, hdr := range msg.Headers {
+		if hdr != nil {
+			headers = append(headers, *hdr)
+		}
+	}
+	return headers
+}
+
 // Subscribe consumes messages from the specified topics with middleware support
 func (c *Client) Subscribe(ctx context.Context, topics []string, handler func(context.Context, *sarama.ConsumerMessage) error, middlewares ...Middleware) error {
+	chain := make([]ConsumerMiddleware, len(middlewares))
+	for i, mw := range middlewares {
+		chain[i] = AdaptMiddleware(mw)
+	}
+
+	return c.SubscribeMessages(ctx, topics, func(ctx context.Context, msg *Message) error {
+		return handler(ctx, msg.Raw)
+	}, chain...)
+}
+
+// SubscribeMessages consumes messages from the specified topics through a
+// metadata-aware middleware chain; the first middleware is the outermost
+func (c *Client) SubscribeMessages(ctx context.Context, topics []string, handler Handler, middlewares ...ConsumerMiddleware) error {
 	consumerHandler := &ConsumerGroupHandler{
-		handler:     handler,
-		middlewares: middlewares,
-		topic:       topics[0],
+		client:  c,
+		handler: Chain(handler, middlewares...),
 	}
 
+	return c.consume(ctx, topics, consumerHandler)
+}
+
+// consume runs a consumer group session loop until ctx is cancelled or the group is closed
+func (c *Client) consume(ctx context.Context, topics []string, handler sarama.ConsumerGroupHandler) error {
+	return c.consumeGroup(ctx, c.conn.ConsumerGroup, topics, handler)
+}
+
+// consumeGroup is consume on the consumer group returned by getGroup
+func (c *Client) consumeGroup(ctx context.Context, getGroup func() (sarama.ConsumerGroup, error), topics []string, handler sarama.ConsumerGroupHandler) error {
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		default:
-			err := c.conn.Consumer.Consume(ctx, topics, consumerHandler)
+			group, err := getGroup()
+			if err != nil {
+				return err
+			}
+			err = group.Consume(ctx, topics, handler)
 			if err != nil {
 				if err == sarama.ErrClosedConsumerGroup {
 					return nil
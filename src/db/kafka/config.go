@@ -1,7 +1,7 @@
 package kafka
 
 type KafkaConfig struct {
-	BootstrapServers  string
+	BootstrapServers  string // Comma-separated broker list (host1:9092,host2:9092)
 	ClientID          string
 	GroupID           string
 	MaxRetries        int
@@ -9,7 +9,48 @@ type KafkaConfig struct {
 	DeadLetterTopic   string
 	RequiredAcks      int    // Maps to Sarama's RequiredAcks (e.g., WaitForAll, WaitForLocal)
 	Idempotent        bool   // Enable idempotent producer
+	TransactionalID   string // Enables the transactional producer when set (requires Idempotent)
 	AutoOffsetReset   string // Consumer offset reset policy (earliest, latest)
+	ManualCommit      bool   // Disable auto-commit; handlers must call Message.Ack
+
+	DeadLetterPartitions        int   // Partitions of the DLQ topic
+	DeadLetterReplicationFactor int   // Replication of the DLQ topic, capped at the broker count
+	DeadLetterRetentionMs       int64 // retention.ms of the DLQ topic; broker default when zero
+
+	Topics []TopicSpec // Topics ensured to exist when the client is created
+
+	ConsumerMaxRetries     int // Handler attempts per message before it is sent to the DLQ
+	ConsumerRetryBackoffMs int // Initial delay between handler attempts, doubled on every retry
+	ConsumerMaxBackoffMs   int // Upper bound for the handler retry delay
+	ClaimWorkers           int // Parallel workers per partition claim; messages with the same key stay ordered
+	ClaimMaxInFlight       int // Maximum unfinished messages per partition claim when ClaimWorkers > 1
+
+	KafkaVersion         string // Broker protocol version, e.g. 2.8.0; sarama's default when empty
+	SessionTimeoutMs     int    // Consumer group session timeout
+	HeartbeatIntervalMs  int    // Consumer group heartbeat interval
+	MaxProcessingTimeMs  int    // Time a handler may take before the partition fetcher stalls
+	AutoCommitIntervalMs int    // Offset auto-commit interval
+	RebalanceStrategy    string // range, roundrobin, sticky or cooperative-sticky
+	IsolationLevel       string // read_committed or read_uncommitted
+	FetchMinBytes        int32  // Minimum bytes a fetch request waits for
+	FetchDefaultBytes    int32  // Bytes requested per partition per fetch
+	FetchMaxBytes        int32  // Maximum bytes per partition per fetch; unlimited when zero
+	FetchMaxWaitMs       int    // Maximum time the broker waits for FetchMinBytes
+
+	TLSEnabled            bool   // Encrypt broker connections
+	TLSCAFile             string // PEM CA bundle used to verify brokers; system pool when empty
+	TLSCertFile           string // PEM client certificate for mutual TLS
+	TLSKeyFile            string // PEM client key for mutual TLS
+	TLSInsecureSkipVerify bool   // Skip broker certificate verification (dev only)
+
+	SASLMechanism string // PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512; SASL is disabled when empty
+	SASLUsername  string
+	SASLPassword  string
+
+	AsyncFlushMessages    int    // Messages buffered by the async producer before a flush
+	AsyncFlushFrequencyMs int    // Maximum time the async producer buffers before a flush
+	AsyncFlushBytes       int    // Buffered bytes that trigger an async flush
+	Compression           string // none, gzip, snappy, lz4 or zstd
 }
 
 func DefaultConfig() *KafkaConfig {
@@ -23,6 +64,29 @@ func DefaultConfig() *KafkaConfig {
 		RequiredAcks:      -1,
 		Idempotent:        true,
 		AutoOffsetReset:   "earliest",
+
+		DeadLetterPartitions:        2,
+		DeadLetterReplicationFactor: 2,
+
+		ConsumerMaxRetries:     3,
+		ConsumerRetryBackoffMs: 500,
+		ConsumerMaxBackoffMs:   10000,
+		ClaimWorkers:           1,
+		ClaimMaxInFlight:       100,
+
+		SessionTimeoutMs:     6000,
+		HeartbeatIntervalMs:  2000,
+		MaxProcessingTimeMs:  300,
+		AutoCommitIntervalMs: 5000,
+		RebalanceStrategy:    "range",
+		IsolationLevel:       "read_committed",
+		FetchMinBytes:        1,
+		FetchDefaultBytes:    1024 * 1024,
+		FetchMaxWaitMs:       250,
+
+		AsyncFlushMessages:    500,
+		AsyncFlushFrequencyMs: 100,
+		Compression:           "none",
 	}
 }
 
@@ -46,14 +110,116 @@ func LoadKafkaConfig(cfg KafkaConfig) *KafkaConfig {
 	if cfg.DeadLetterTopic != "" {
 		defaultCfg.DeadLetterTopic = cfg.DeadLetterTopic
 	}
+	if cfg.DeadLetterPartitions > 0 {
+		defaultCfg.DeadLetterPartitions = cfg.DeadLetterPartitions
+	}
+	if cfg.DeadLetterReplicationFactor > 0 {
+		defaultCfg.DeadLetterReplicationFactor = cfg.DeadLetterReplicationFactor
+	}
+	if cfg.DeadLetterRetentionMs > 0 {
+		defaultCfg.DeadLetterRetentionMs = cfg.DeadLetterRetentionMs
+	}
+	if len(cfg.Topics) > 0 {
+		defaultCfg.Topics = cfg.Topics
+	}
 	if cfg.RequiredAcks != 0 {
 		defaultCfg.RequiredAcks = cfg.RequiredAcks
 	}
 	if cfg.Idempotent {
 		defaultCfg.Idempotent = cfg.Idempotent
 	}
+	if cfg.TransactionalID != "" {
+		defaultCfg.TransactionalID = cfg.TransactionalID
+	}
+	if cfg.ManualCommit {
+		defaultCfg.ManualCommit = cfg.ManualCommit
+	}
 	if cfg.AutoOffsetReset != "" {
 		defaultCfg.AutoOffsetReset = cfg.AutoOffsetReset
 	}
+	if cfg.ConsumerMaxRetries > 0 {
+		defaultCfg.ConsumerMaxRetries = cfg.ConsumerMaxRetries
+	}
+	if cfg.ConsumerRetryBackoffMs > 0 {
+		defaultCfg.ConsumerRetryBackoffMs = cfg.ConsumerRetryBackoffMs
+	}
+	if cfg.ConsumerMaxBackoffMs > 0 {
+		defaultCfg.ConsumerMaxBackoffMs = cfg.ConsumerMaxBackoffMs
+	}
+	if cfg.ClaimWorkers > 0 {
+		defaultCfg.ClaimWorkers = cfg.ClaimWorkers
+	}
+	if cfg.ClaimMaxInFlight > 0 {
+		defaultCfg.ClaimMaxInFlight = cfg.ClaimMaxInFlight
+	}
+	if cfg.KafkaVersion != "" {
+		defaultCfg.KafkaVersion = cfg.KafkaVersion
+	}
+	if cfg.SessionTimeoutMs > 0 {
+		defaultCfg.SessionTimeoutMs = cfg.SessionTimeoutMs
+	}
+	if cfg.HeartbeatIntervalMs > 0 {
+		defaultCfg.HeartbeatIntervalMs = cfg.HeartbeatIntervalMs
+	}
+	if cfg.MaxProcessingTimeMs > 0 {
+		defaultCfg.MaxProcessingTimeMs = cfg.MaxProcessingTimeMs
+	}
+	if cfg.AutoCommitIntervalMs > 0 {
+		defaultCfg.AutoCommitIntervalMs = cfg.AutoCommitIntervalMs
+	}
+	if cfg.RebalanceStrategy != "" {
+		defaultCfg.RebalanceStrategy = cfg.RebalanceStrategy
+	}
+	if cfg.IsolationLevel != "" {
+		defaultCfg.IsolationLevel = cfg.IsolationLevel
+	}
+	if cfg.FetchMinBytes > 0 {
+		defaultCfg.FetchMinBytes = cfg.FetchMinBytes
+	}
+	if cfg.FetchDefaultBytes > 0 {
+		defaultCfg.FetchDefaultBytes = cfg.FetchDefaultBytes
+	}
+	if cfg.FetchMaxBytes > 0 {
+		defaultCfg.FetchMaxBytes = cfg.FetchMaxBytes
+	}
+	if cfg.FetchMaxWaitMs > 0 {
+		defaultCfg.FetchMaxWaitMs = cfg.FetchMaxWaitMs
+	}
+	if cfg.TLSEnabled {
+		defaultCfg.TLSEnabled = cfg.TLSEnabled
+	}
+	if cfg.TLSCAFile != "" {
+		defaultCfg.TLSCAFile = cfg.TLSCAFile
+	}
+	if cfg.TLSCertFile != "" {
+		defaultCfg.TLSCertFile = cfg.TLSCertFile
+	}
+	if cfg.TLSKeyFile != "" {
+		defaultCfg.TLSKeyFile = cfg.TLSKeyFile
+	}
+	if cfg.TLSInsecureSkipVerify {
+		defaultCfg.TLSInsecureSkipVerify = cfg.TLSInsecureSkipVerify
+	}
+	if cfg.SASLMechanism != "" {
+		defaultCfg.SASLMechanism = cfg.SASLMechanism
+	}
+	if cfg.SASLUsername != "" {
+		defaultCfg.SASLUsername = cfg.SASLUsername
+	}
+	if cfg.SASLPassword != "" {
+		defaultCfg.SASLPassword = cfg.SASLPassword
+	}
+	if cfg.AsyncFlushMessages > 0 {
+		defaultCfg.AsyncFlushMessages = cfg.AsyncFlushMessages
+	}
+	if cfg.AsyncFlushFrequencyMs > 0 {
+		defaultCfg.AsyncFlushFrequencyMs = cfg.AsyncFlushFrequencyMs
+	}
+	if cfg.AsyncFlushBytes > 0 {
+		defaultCfg.AsyncFlushBytes = cfg.AsyncFlushBytes
+	}
+	if cfg.Compression != "" {
+		defaultCfg.Compression = cfg.Compression
+	}
 	return defaultCfg
 }
@@ -2,22 +2,158 @@ package kafka
 
 import (
 	"fmt"
+	"log"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/IBM/sarama"
 )
 
+// KafkaConn shares one sarama.Client between a producer, a consumer group and
+// an admin client. Connections made by ConnectFromEnv have all three; the
+// client constructors create each one the first time it is needed, so use the
+// accessors rather than the fields on those.
 type KafkaConn struct {
+	Client       sarama.Client
 	Producer     sarama.SyncProducer
 	Consumer     sarama.ConsumerGroup
 	Admin        sarama.ClusterAdmin
 	ConsumerDone chan struct{}
+
+	brokers     []string
+	groupID     string
+	txnConsumer sarama.ConsumerGroup // Same group ID, offsets committed only through producer transactions
+	mu          sync.Mutex
 }
 
+// ConnectFromEnv connects and creates the producer, the consumer group (when
+// GroupID is set) and the admin client up front
 func ConnectFromEnv(cfg KafkaConfig) (*KafkaConn, error) {
-	config := LoadKafkaConfig(cfg)
+	conn, err := connect(LoadKafkaConfig(cfg))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.SyncProducer(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if conn.groupID != "" {
+		if _, err := conn.ConsumerGroup(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if _, err := conn.ClusterAdmin(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// connect creates only the shared sarama.Client
+func connect(config *KafkaConfig) (*KafkaConn, error) {
+	saramaConfig, err := newSaramaConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	brokers := parseBrokers(config.BootstrapServers)
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("no Kafka brokers configured")
+	}
+
+	client, err := sarama.NewClient(brokers, saramaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	return &KafkaConn{
+		Client:       client,
+		ConsumerDone: make(chan struct{}),
+		brokers:      brokers,
+		groupID:      config.GroupID,
+	}, nil
+}
+
+// SyncProducer returns the shared producer, creating it on first use
+func (c *KafkaConn) SyncProducer() (sarama.SyncProducer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.Producer == nil {
+		producer, err := sarama.NewSyncProducerFromClient(c.Client)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create producer: %w", err)
+		}
+		c.Producer = producer
+	}
+	return c.Producer, nil
+}
+
+// ConsumerGroup returns the consumer group, creating it on first use
+func (c *KafkaConn) ConsumerGroup() (sarama.ConsumerGroup, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.Consumer == nil {
+		if c.groupID == "" {
+			return nil, fmt.Errorf("failed to create consumer group: GroupID is required")
+		}
+		group, err := sarama.NewConsumerGroupFromClient(c.groupID, c.Client)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create consumer group: %w", err)
+		}
+		c.Consumer = group
+	}
+	return c.Consumer, nil
+}
+
+// TxnConsumerGroup returns the consumer group used for consume-transform-produce,
+// creating it on first use. Its offsets are committed with AddMessageToTxn, so
+// it runs on its own client with auto-commit disabled rather than sharing the
+// auto-committing one.
+func (c *KafkaConn) TxnConsumerGroup() (sarama.ConsumerGroup, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	// Sarama configuration
+	if c.txnConsumer == nil {
+		if c.groupID == "" {
+			return nil, fmt.Errorf("failed to create transactional consumer group: GroupID is required")
+		}
+		group, err := sarama.NewConsumerGroup(c.brokers, c.groupID, txnConsumerConfig(c.Client.Config()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create transactional consumer group: %w", err)
+		}
+		c.txnConsumer = group
+	}
+	return c.txnConsumer, nil
+}
+
+// txnConsumerConfig copies base with offset auto-commit disabled
+func txnConsumerConfig(base *sarama.Config) *sarama.Config {
+	cfg := *base
+	cfg.Consumer.Offsets.AutoCommit.Enable = false
+	return &cfg
+}
+
+// ClusterAdmin returns the admin client, creating it on first use
+func (c *KafkaConn) ClusterAdmin() (sarama.ClusterAdmin, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.Admin == nil {
+		admin, err := sarama.NewClusterAdminFromClient(c.Client)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create admin client: %w", err)
+		}
+		c.Admin = admin
+	}
+	return c.Admin, nil
+}
+
+func newSaramaConfig(config *KafkaConfig) (*sarama.Config, error) {
 	saramaConfig := sarama.NewConfig()
 	saramaConfig.ClientID = config.ClientID
 	saramaConfig.Producer.RequiredAcks = sarama.RequiredAcks(config.RequiredAcks)
@@ -25,62 +161,87 @@ func ConnectFromEnv(cfg KafkaConfig) (*KafkaConn, error) {
 	saramaConfig.Producer.Retry.Backoff = time.Duration(config.RetryDelaySeconds) * time.Second
 	saramaConfig.Producer.Idempotent = config.Idempotent
 	saramaConfig.Producer.Return.Successes = true
-	saramaConfig.Net.MaxOpenRequests = 5 // Required for idempotence
-	saramaConfig.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategyRange
+	saramaConfig.Net.MaxOpenRequests = 5
 	saramaConfig.Consumer.Offsets.Initial = parseOffset(config.AutoOffsetReset)
-	saramaConfig.Consumer.Offsets.AutoCommit.Enable = true
-	saramaConfig.Consumer.Offsets.AutoCommit.Interval = 5 * time.Second
-	saramaConfig.Consumer.Group.Session.Timeout = 6 * time.Second
-	saramaConfig.Consumer.Group.Heartbeat.Interval = 2 * time.Second
-	saramaConfig.Consumer.MaxProcessingTime = 300 * time.Millisecond
+	saramaConfig.Consumer.Offsets.AutoCommit.Enable = !config.ManualCommit
+	saramaConfig.Consumer.Offsets.AutoCommit.Interval = time.Duration(config.AutoCommitIntervalMs) * time.Millisecond
+	saramaConfig.Consumer.Group.Session.Timeout = time.Duration(config.SessionTimeoutMs) * time.Millisecond
+	saramaConfig.Consumer.Group.Heartbeat.Interval = time.Duration(config.HeartbeatIntervalMs) * time.Millisecond
+	saramaConfig.Consumer.MaxProcessingTime = time.Duration(config.MaxProcessingTimeMs) * time.Millisecond
+	saramaConfig.Consumer.Fetch.Min = config.FetchMinBytes
+	saramaConfig.Consumer.Fetch.Default = config.FetchDefaultBytes
+	saramaConfig.Consumer.Fetch.Max = config.FetchMaxBytes
+	saramaConfig.Consumer.MaxWaitTime = time.Duration(config.FetchMaxWaitMs) * time.Millisecond
 
-	if config.Idempotent {
-		saramaConfig.Producer.Transaction.ID = config.ClientID + "-txn"
+	if config.KafkaVersion != "" {
+		version, err := sarama.ParseKafkaVersion(config.KafkaVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Kafka version %q: %w", config.KafkaVersion, err)
+		}
+		saramaConfig.Version = version
 	}
 
-	// Create producer
-	producer, err := sarama.NewSyncProducer([]string{config.BootstrapServers}, saramaConfig)
+	strategy, err := parseBalanceStrategy(config.RebalanceStrategy)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create producer: %w", err)
+		return nil, err
 	}
+	saramaConfig.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{strategy}
 
-	// Create consumer group
-	consumerGroup, err := sarama.NewConsumerGroup([]string{config.BootstrapServers}, config.GroupID, saramaConfig)
+	isolation, err := parseIsolationLevel(config.IsolationLevel)
 	if err != nil {
-		producer.Close()
-		return nil, fmt.Errorf("failed to create consumer group: %w", err)
+		return nil, err
 	}
+	saramaConfig.Consumer.IsolationLevel = isolation
 
-	// Create admin client
-	admin, err := sarama.NewClusterAdmin([]string{config.BootstrapServers}, saramaConfig)
-	if err != nil {
-		producer.Close()
-		consumerGroup.Close()
-		return nil, fmt.Errorf("failed to create admin client: %w", err)
+	if config.Idempotent {
+		saramaConfig.Net.MaxOpenRequests = 1 // Required for idempotence
+		saramaConfig.Producer.Transaction.ID = config.TransactionalID
 	}
 
-	return &KafkaConn{
-		Producer:     producer,
-		Consumer:     consumerGroup,
-		Admin:        admin,
-		ConsumerDone: make(chan struct{}),
-	}, nil
+	if err := applySecurity(saramaConfig, config); err != nil {
+		return nil, err
+	}
+	return saramaConfig, nil
+}
+
+// parseBrokers splits a comma-separated broker list, dropping blanks
+func parseBrokers(servers string) []string {
+	var brokers []string
+	for _, b := range strings.Split(servers, ",") {
+		if b = strings.TrimSpace(b); b != "" {
+			brokers = append(brokers, b)
+		}
+	}
+	return brokers
 }
 
 func (c *KafkaConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	var errs []error
 
-	if err := c.Consumer.Close(); err != nil {
-		errs = append(errs, fmt.Errorf("failed to close consumer group: %w", err))
+	if c.Consumer != nil {
+		if err := c.Consumer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close consumer group: %w", err))
+		}
+	}
+	if c.txnConsumer != nil {
+		if err := c.txnConsumer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close transactional consumer group: %w", err))
+		}
 	}
 	close(c.ConsumerDone)
 
-	if err := c.Producer.Close(); err != nil {
-		errs = append(errs, fmt.Errorf("failed to close producer: %w", err))
+	if c.Producer != nil {
+		if err := c.Producer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close producer: %w", err))
+		}
 	}
 
-	if err := c.Admin.Close(); err != nil {
-		errs = append(errs, fmt.Errorf("failed to close admin client: %w", err))
+	// Closing the admin client would close the shared client, so only the client is closed
+	if err := c.Client.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to close client: %w", err))
 	}
 
 	if len(errs) > 0 {
@@ -99,3 +260,32 @@ func parseOffset(offset string) int64 {
 		return sarama.OffsetOldest
 	}
 }
+
+func parseBalanceStrategy(strategy string) (sarama.BalanceStrategy, error) {
+	switch strings.ToLower(strategy) {
+	case "", "range":
+		return sarama.NewBalanceStrategyRange(), nil
+	case "roundrobin", "round-robin":
+		return sarama.NewBalanceStrategyRoundRobin(), nil
+	case "sticky":
+		return sarama.NewBalanceStrategySticky(), nil
+	case "cooperative-sticky":
+		// Sarama only implements the eager rebalance protocol, so this keeps
+		// sticky assignments but still revokes all partitions on rebalance
+		log.Printf("Kafka rebalance strategy cooperative-sticky uses sticky assignment with eager rebalancing")
+		return sarama.NewBalanceStrategySticky(), nil
+	default:
+		return nil, fmt.Errorf("unsupported rebalance strategy: %s", strategy)
+	}
+}
+
+func parseIsolationLevel(level string) (sarama.IsolationLevel, error) {
+	switch strings.ToLower(level) {
+	case "", "read_committed":
+		return sarama.ReadCommitted, nil
+	case "read_uncommitted":
+		return sarama.ReadUncommitted, nil
+	default:
+		return sarama.ReadUncommitted, fmt.Errorf("unsupported isolation level: %s", level)
+	}
+}
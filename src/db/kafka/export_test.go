@@ -0,0 +1,42 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/IBM/sarama"
+)
+
+// NewTestClient returns a Client that sends through producer, so the external
+// tests can compare it with kafkatest.Client
+func NewTestClient(config *KafkaConfig, producer sarama.SyncProducer) *Client {
+	return &Client{conn: &KafkaConn{Producer: producer}, config: config}
+}
+
+// ConsumeTestMessages runs msgs through the consumer group handler used by
+// SubscribeMessages, as one claim of a session that ends with ctx
+func (c *Client) ConsumeTestMessages(ctx context.Context, msgs []*sarama.ConsumerMessage, handler Handler) error {
+	claim := &testClaim{messages: make(chan *sarama.ConsumerMessage, len(msgs))}
+	for _, msg := range msgs {
+		claim.messages <- msg
+	}
+	close(claim.messages)
+
+	h := &ConsumerGroupHandler{client: c, handler: handler}
+	return h.ConsumeClaim(&testSession{ctx: ctx}, claim)
+}
+
+type testSession struct {
+	sarama.ConsumerGroupSession
+	ctx context.Context
+}
+
+func (s *testSession) Context() context.Context                    { return s.ctx }
+func (s *testSession) MarkMessage(*sarama.ConsumerMessage, string) {}
+func (s *testSession) Commit()                                     {}
+
+type testClaim struct {
+	sarama.ConsumerGroupClaim
+	messages chan *sarama.ConsumerMessage
+}
+
+func (c *testClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
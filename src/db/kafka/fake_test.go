@@ -0,0 +1,183 @@
+package kafka_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+
+	"github.com/Ajinx1/go-storage-config/src/db/kafka"
+	"github.com/Ajinx1/go-storage-config/src/db/kafka/kafkatest"
+)
+
+var errSend = errors.New("broker unavailable")
+
+// recordingProducer fails the first sends to a topic and records the rest
+type recordingProducer struct {
+	sarama.SyncProducer
+	failures map[string]int
+	sent     []*sarama.ProducerMessage
+}
+
+func (p *recordingProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	if p.failures[msg.Topic] > 0 {
+		p.failures[msg.Topic]--
+		return 0, 0, errSend
+	}
+	p.sent = append(p.sent, msg)
+	return 0, int64(len(p.sent) - 1), nil
+}
+
+func (p *recordingProducer) deadLetters(topic string) []kafkatest.Record {
+	var out []kafkatest.Record
+	for _, msg := range p.sent {
+		if msg.Topic != topic {
+			continue
+		}
+		key, _ := msg.Key.Encode()
+		value, _ := msg.Value.Encode()
+		out = append(out, kafkatest.Record{Topic: msg.Topic, Key: key, Value: value, Headers: msg.Headers})
+	}
+	return out
+}
+
+// parityConfig keeps the real client's backoff short; the fake skips it anyway
+func parityConfig() *kafka.KafkaConfig {
+	config := kafka.DefaultConfig()
+	config.Idempotent = false
+	config.MaxRetries = 2
+	config.RetryDelaySeconds = 0
+	config.ConsumerMaxRetries = 3
+	config.ConsumerRetryBackoffMs = 1
+	config.ConsumerMaxBackoffMs = 1
+	return config
+}
+
+// normalize renders records for comparison, without the timestamp header's value
+func normalize(records []kafkatest.Record) []string {
+	var out []string
+	for _, r := range records {
+		s := fmt.Sprintf("%s key=%s value=%s", r.Topic, r.Key, r.Value)
+		for _, h := range r.Headers {
+			value := string(h.Value)
+			if string(h.Key) == "timestamp" {
+				value = "*"
+			}
+			s += fmt.Sprintf(" %s=%s", h.Key, value)
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func TestFakePublishMatchesClient(t *testing.T) {
+	headers := []sarama.RecordHeader{{Key: []byte("trace-id"), Value: []byte("t-1")}}
+
+	tests := []struct {
+		name     string
+		failures int
+		wantErr  bool
+	}{
+		{name: "succeeds within retries", failures: 2},
+		{name: "dead-lettered after retries", failures: 3, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := parityConfig()
+			ctx := context.Background()
+
+			producer := &recordingProducer{failures: map[string]int{"orders": tt.failures}}
+			real := kafka.NewTestClient(config, producer)
+			realErr := real.PublishWithHeaders(ctx, "orders", []byte("k"), []byte("v"), headers)
+
+			fake := kafkatest.NewClient(*config)
+			fake.FailPublish("orders", errSend, tt.failures)
+			fakeErr := fake.PublishWithHeaders(ctx, "orders", []byte("k"), []byte("v"), headers)
+
+			if (realErr != nil) != tt.wantErr || (fakeErr != nil) != tt.wantErr {
+				t.Fatalf("errors: real %v, fake %v, want error %t", realErr, fakeErr, tt.wantErr)
+			}
+			want := normalize(producer.deadLetters(config.DeadLetterTopic))
+			if tt.wantErr != (len(want) == 1) {
+				t.Fatalf("real client dead-lettered %v", want)
+			}
+			if got := normalize(fake.DeadLetters()); !reflect.DeepEqual(got, want) {
+				t.Errorf("fake dead letters\n%v\nreal dead letters\n%v", got, want)
+			}
+		})
+	}
+}
+
+func TestFakeConsumerMatchesClient(t *testing.T) {
+	errHandler := errors.New("handler failed")
+
+	tests := []struct {
+		name     string
+		failures int // Handler failures before it succeeds
+	}{
+		{name: "recovers within retries", failures: 2},
+		{name: "dead-lettered after retries", failures: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := parityConfig()
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			handler := func(attempts *int) kafka.Handler {
+				return func(context.Context, *kafka.Message) error {
+					*attempts++
+					if *attempts <= tt.failures {
+						return errHandler
+					}
+					return nil
+				}
+			}
+
+			producer := &recordingProducer{}
+			real := kafka.NewTestClient(config, producer)
+			msg := &sarama.ConsumerMessage{
+				Topic:   "orders",
+				Key:     []byte("k"),
+				Value:   []byte("v"),
+				Headers: []*sarama.RecordHeader{{Key: []byte("trace-id"), Value: []byte("t-1")}},
+			}
+			var realAttempts int
+			if err := real.ConsumeTestMessages(ctx, []*sarama.ConsumerMessage{msg}, handler(&realAttempts)); err != nil {
+				t.Fatalf("real consumer failed: %v", err)
+			}
+
+			fake := kafkatest.NewClient(*config)
+			fake.Inject("orders", msg.Key, msg.Value, *msg.Headers[0])
+			var fakeAttempts int
+			subCtx, stop := context.WithCancel(ctx)
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				fake.SubscribeMessages(subCtx, []string{"orders"}, handler(&fakeAttempts))
+			}()
+			if err := fake.WaitConsumed(ctx, "orders", 1); err != nil {
+				t.Fatalf("fake consumer did not finish: %v", err)
+			}
+			stop()
+			<-done
+
+			if fakeAttempts != realAttempts {
+				t.Errorf("fake ran the handler %d times, real %d", fakeAttempts, realAttempts)
+			}
+			want := normalize(producer.deadLetters(config.DeadLetterTopic))
+			if (tt.failures >= config.ConsumerMaxRetries) != (len(want) == 1) {
+				t.Fatalf("real client dead-lettered %v", want)
+			}
+			if got := normalize(fake.DeadLetters()); !reflect.DeepEqual(got, want) {
+				t.Errorf("fake dead letters\n%v\nreal dead letters\n%v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package kafkatest
+
+import (
+	"fmt"
+
+	"github.com/Ajinx1/go-storage-config/src/db/kafka"
+	"github.com/IBM/sarama"
+)
+
+// DescribeTopic returns the layout recorded by EnsureTopic
+func (c *Client) DescribeTopic(name string) (*kafka.TopicDescription, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	detail, ok := c.topics[name]
+	if !ok {
+		return nil, fmt.Errorf("failed to describe topic %s: %w", name, sarama.ErrUnknownTopicOrPartition)
+	}
+
+	desc := &kafka.TopicDescription{
+		Name:              name,
+		ReplicationFactor: int(detail.ReplicationFactor),
+		Configs:           make(map[string]string, len(detail.ConfigEntries)),
+	}
+	for i := int32(0); i < detail.NumPartitions; i++ {
+		desc.Partitions = append(desc.Partitions, &sarama.PartitionMetadata{ID: i})
+	}
+	for k, v := range detail.ConfigEntries {
+		if v != nil {
+			desc.Configs[k] = *v
+		}
+	}
+	return desc, nil
+}
+
+// ResetOffsets moves the position the next subscription on topic starts from.
+// The fake has a single consumer group, so groupID only names it in errors; like the real
+// client it fails with kafka.ErrGroupActive while topic is being consumed.
+func (c *Client) ResetOffsets(groupID, topic string, reset kafka.OffsetReset) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.active[topic] > 0 {
+		return fmt.Errorf("%w: %s", kafka.ErrGroupActive, groupID)
+	}
+
+	if len(reset.Offsets) > 0 {
+		if offset, ok := reset.Offsets[0]; ok {
+			c.consumed[topic] = offset
+		}
+		return nil
+	}
+
+	log := c.logs[topic]
+	switch {
+	case reset.Earliest:
+		c.consumed[topic] = 0
+	case reset.Latest:
+		c.consumed[topic] = int64(len(log))
+	case !reset.Timestamp.IsZero():
+		next := int64(len(log))
+		for _, r := range log {
+			if !r.Timestamp.Before(reset.Timestamp) {
+				next = r.Offset
+				break
+			}
+		}
+		c.consumed[topic] = next
+	default:
+		return fmt.Errorf("offset reset for topic %s has no target", topic)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package kafkatest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Ajinx1/go-storage-config/src/db/kafka"
+	"github.com/IBM/sarama"
+)
+
+// asyncPublisher mimics kafka.AsyncPublisher: each message is tried once,
+// failures are reported to OnError and dead-lettered. Callbacks run before
+// Publish returns instead of on delivery goroutines.
+type asyncPublisher struct {
+	client    *Client
+	callbacks kafka.AsyncCallbacks
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func (c *Client) NewAsyncPublisher(callbacks kafka.AsyncCallbacks) (kafka.AsyncAPI, error) {
+	return &asyncPublisher{client: c, callbacks: callbacks}, nil
+}
+
+func (p *asyncPublisher) Publish(ctx context.Context, topic string, key, value []byte, middlewares ...kafka.Middleware) error {
+	return p.PublishWithHeaders(ctx, topic, key, value, nil, middlewares...)
+}
+
+func (p *asyncPublisher) PublishWithHeaders(ctx context.Context, topic string, key, value []byte, headers []sarama.RecordHeader, middlewares ...kafka.Middleware) error {
+	for _, mw := range middlewares {
+		if err := mw(ctx, topic, value); err != nil {
+			return fmt.Errorf("middleware failed: %w", err)
+		}
+	}
+
+	p.mu.Lock()
+	closed := p.closed
+	p.mu.Unlock()
+	if closed {
+		return kafka.ErrPublisherClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	msg := &sarama.ProducerMessage{
+		Topic:   topic,
+		Key:     sarama.ByteEncoder(key),
+		Value:   sarama.ByteEncoder(value),
+		Headers: headers,
+	}
+
+	c := p.client
+	c.mu.Lock()
+	if c.isClosed {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	err := c.takeFailureLocked(topic)
+	if err == nil {
+		r := c.appendLocked(topic, key, value, headers)
+		c.published = append(c.published, r)
+		msg.Partition, msg.Offset = r.Partition, r.Offset
+	} else {
+		c.appendLocked(c.config.DeadLetterTopic, key, value,
+			kafka.DeadLetterHeaders(topic, kafka.DeliveryFailureHeaders(err, headers)...))
+	}
+	c.mu.Unlock()
+
+	if err != nil {
+		if p.callbacks.OnError != nil {
+			p.callbacks.OnError(msg, err)
+		}
+	} else if p.callbacks.OnSuccess != nil {
+		p.callbacks.OnSuccess(msg)
+	}
+	return nil
+}
+
+func (p *asyncPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
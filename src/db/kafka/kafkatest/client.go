@@ -0,0 +1,443 @@
+// Package kafkatest provides an in-memory stand-in for kafka.Client so handler,
+// retry and DLQ logic can be unit tested without a broker.
+package kafkatest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Ajinx1/go-storage-config/src/db/kafka"
+	"github.com/IBM/sarama"
+)
+
+var ErrClosed = errors.New("kafkatest: client is closed")
+
+// Record is a message stored in a fake topic
+type Record struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   []sarama.RecordHeader
+	Timestamp time.Time
+}
+
+// Client mimics kafka.Client: publishes are retried MaxRetries times and then
+// dead-lettered, and consumed messages are retried ConsumerMaxRetries times and
+// then dead-lettered with the same headers as the real client. Backoff delays
+// are skipped. Every topic has a single partition.
+type Client struct {
+	config *kafka.KafkaConfig
+
+	mu        sync.Mutex
+	logs      map[string][]*Record
+	consumed  map[string]int64 // Next offset to deliver per topic
+	settled   map[string]int   // Messages fully handled per topic
+	published []*Record
+	failures  map[string][]error
+	topics    map[string]sarama.TopicDetail
+	active    map[string]int // Running subscriptions per topic
+	changed   chan struct{}
+	closed    chan struct{}
+	isClosed  bool
+}
+
+var _ kafka.API = (*Client)(nil)
+
+func NewClient(cfg kafka.KafkaConfig) *Client {
+	return &Client{
+		config:   kafka.LoadKafkaConfig(cfg),
+		logs:     make(map[string][]*Record),
+		consumed: make(map[string]int64),
+		settled:  make(map[string]int),
+		failures: make(map[string][]error),
+		topics:   make(map[string]sarama.TopicDetail),
+		active:   make(map[string]int),
+		changed:  make(chan struct{}),
+		closed:   make(chan struct{}),
+	}
+}
+
+// Published returns the messages published to topic through the client, or all when topic is empty
+func (c *Client) Published(topic string) []Record {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var out []Record
+	for _, r := range c.published {
+		if topic == "" || r.Topic == topic {
+			out = append(out, *r)
+		}
+	}
+	return out
+}
+
+// DeadLetters returns the messages routed to the DLQ topic
+func (c *Client) DeadLetters() []Record {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := make([]Record, 0, len(c.logs[c.config.DeadLetterTopic]))
+	for _, r := range c.logs[c.config.DeadLetterTopic] {
+		out = append(out, *r)
+	}
+	return out
+}
+
+// Inject appends a message to topic as if another producer had written it
+func (c *Client) Inject(topic string, key, value []byte, headers ...sarama.RecordHeader) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.appendLocked(topic, key, value, headers)
+}
+
+// FailPublish makes the next times publish attempts to topic fail with err
+func (c *Client) FailPublish(topic string, err error, times int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := 0; i < times; i++ {
+		c.failures[topic] = append(c.failures[topic], err)
+	}
+}
+
+// WaitConsumed blocks until n messages of topic have been handled (processed or dead-lettered)
+func (c *Client) WaitConsumed(ctx context.Context, topic string, n int) error {
+	for {
+		c.mu.Lock()
+		done := c.settled[topic] >= n
+		changed := c.changed
+		c.mu.Unlock()
+		if done {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+func (c *Client) Publish(ctx context.Context, topic string, key, value []byte, middlewares ...kafka.Middleware) error {
+	return c.PublishWithHeaders(ctx, topic, key, value, nil, middlewares...)
+}
+
+func (c *Client) PublishWithHeaders(ctx context.Context, topic string, key, value []byte, headers []sarama.RecordHeader, middlewares ...kafka.Middleware) error {
+	for _, mw := range middlewares {
+		if err := mw(ctx, topic, value); err != nil {
+			return fmt.Errorf("middleware failed: %w", err)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.isClosed {
+		return ErrClosed
+	}
+
+	var lastErr error
+	for i := 0; i <= c.config.MaxRetries; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if lastErr = c.takeFailureLocked(topic); lastErr == nil {
+			c.published = append(c.published, c.appendLocked(topic, key, value, headers))
+			return nil
+		}
+	}
+
+	c.appendLocked(c.config.DeadLetterTopic, key, value, kafka.DeadLetterHeaders(topic, headers...))
+	return fmt.Errorf("max retries reached, last error: %v", lastErr)
+}
+
+// PublishOnce makes a single attempt and never dead-letters
+func (c *Client) PublishOnce(ctx context.Context, topic string, key, value []byte, headers []sarama.RecordHeader) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.isClosed {
+		return ErrClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := c.takeFailureLocked(topic); err != nil {
+		return err
+	}
+	c.published = append(c.published, c.appendLocked(topic, key, value, headers))
+	return nil
+}
+
+func (c *Client) Subscribe(ctx context.Context, topics []string, handler func(context.Context, *sarama.ConsumerMessage) error, middlewares ...kafka.Middleware) error {
+	chain := make([]kafka.ConsumerMiddleware, len(middlewares))
+	for i, mw := range middlewares {
+		chain[i] = kafka.AdaptMiddleware(mw)
+	}
+	return c.SubscribeMessages(ctx, topics, func(ctx context.Context, msg *kafka.Message) error {
+		return handler(ctx, msg.Raw)
+	}, chain...)
+}
+
+func (c *Client) SubscribeMessages(ctx context.Context, topics []string, handler kafka.Handler, middlewares ...kafka.ConsumerMiddleware) error {
+	h := kafka.Chain(handler, middlewares...)
+	defer c.join(topics)()
+
+	for {
+		batch, err := c.next(ctx, topics, 1)
+		if err != nil {
+			return err
+		}
+		if batch == nil {
+			return nil
+		}
+		msg := batch[0]
+
+		attempts, herr := c.retry(func() error {
+			return h(ctx, kafka.NewMessage(msg))
+		})
+		if herr != nil {
+			c.deadLetter(msg, attempts, herr)
+		}
+		c.settle(msg.Topic, 1)
+	}
+}
+
+func (c *Client) SubscribeBatch(ctx context.Context, topics []string, size int, _ time.Duration, handler kafka.BatchHandler) error {
+	if size <= 0 {
+		size = 100
+	}
+	defer c.join(topics)()
+
+	for {
+		raw, err := c.next(ctx, topics, size)
+		if err != nil {
+			return err
+		}
+		if raw == nil {
+			return nil
+		}
+
+		batch := make([]*kafka.Message, len(raw))
+		for i, msg := range raw {
+			batch[i] = kafka.NewMessage(msg)
+		}
+
+		attempts, herr := c.retry(func() error {
+			return handler(ctx, batch)
+		})
+		for _, msg := range raw {
+			if herr != nil {
+				c.deadLetter(msg, attempts, herr)
+			}
+			c.settle(msg.Topic, 1)
+		}
+	}
+}
+
+func (c *Client) EnsureTopic(spec kafka.TopicSpec) error {
+	if spec.Name == "" {
+		return errors.New("topic name is required")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	detail := c.topics[spec.Name]
+	if spec.Partitions > detail.NumPartitions {
+		detail.NumPartitions = spec.Partitions
+	}
+	if detail.ReplicationFactor == 0 {
+		detail.ReplicationFactor = spec.ReplicationFactor
+	}
+	if len(spec.Configs) > 0 {
+		// Copied so maps returned by ListTopics are never modified
+		entries := make(map[string]*string, len(detail.ConfigEntries)+len(spec.Configs))
+		for k, v := range detail.ConfigEntries {
+			entries[k] = v
+		}
+		for k, v := range spec.Configs {
+			v := v
+			entries[k] = &v
+		}
+		detail.ConfigEntries = entries
+	}
+	c.topics[spec.Name] = detail
+	return nil
+}
+
+func (c *Client) EnsureTopics(specs ...kafka.TopicSpec) error {
+	for _, spec := range specs {
+		if err := c.EnsureTopic(spec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) ListTopics() (map[string]sarama.TopicDetail, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := make(map[string]sarama.TopicDetail, len(c.topics))
+	for name, detail := range c.topics {
+		out[name] = detail
+	}
+	return out, nil
+}
+
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.isClosed {
+		c.isClosed = true
+		close(c.closed)
+	}
+	return nil
+}
+
+// next waits for up to max undelivered messages from one of topics
+func (c *Client) next(ctx context.Context, topics []string, max int) ([]*sarama.ConsumerMessage, error) {
+	for {
+		c.mu.Lock()
+		if c.isClosed {
+			c.mu.Unlock()
+			return nil, nil
+		}
+		for _, topic := range topics {
+			log := c.logs[topic]
+			start := c.consumed[topic]
+			if start >= int64(len(log)) {
+				continue
+			}
+
+			end := start + int64(max)
+			if end > int64(len(log)) {
+				end = int64(len(log))
+			}
+			msgs := make([]*sarama.ConsumerMessage, 0, end-start)
+			for _, r := range log[start:end] {
+				msgs = append(msgs, toConsumerMessage(r))
+			}
+			c.consumed[topic] = end
+			c.mu.Unlock()
+			return msgs, nil
+		}
+		changed := c.changed
+		c.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-c.closed:
+			return nil, nil
+		case <-changed:
+		}
+	}
+}
+
+func (c *Client) retry(fn func() error) (int, error) {
+	var err error
+	for attempt := 1; attempt <= c.config.ConsumerMaxRetries; attempt++ {
+		if err = fn(); err == nil {
+			return attempt, nil
+		}
+	}
+	return c.config.ConsumerMaxRetries, err
+}
+
+// DeadLetter records msg on the DLQ topic with the consumer's failure headers
+func (c *Client) DeadLetter(ctx context.Context, msg *sarama.ConsumerMessage, attempts int, cause error, headers ...sarama.RecordHeader) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	closed := c.isClosed
+	c.mu.Unlock()
+	if closed {
+		return ErrClosed
+	}
+	c.deadLetter(msg, attempts, cause, headers...)
+	return nil
+}
+
+func (c *Client) deadLetter(msg *sarama.ConsumerMessage, attempts int, cause error, extra ...sarama.RecordHeader) {
+	headers := kafka.DeadLetterHeaders(msg.Topic, append(kafka.FailureHeaders(msg, attempts, cause), extra...)...)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.appendLocked(c.config.DeadLetterTopic, msg.Key, msg.Value, headers)
+}
+
+// join marks topics as consumed until the returned func is called
+func (c *Client) join(topics []string) func() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, t := range topics {
+		c.active[t]++
+	}
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for _, t := range topics {
+			c.active[t]--
+		}
+	}
+}
+
+func (c *Client) settle(topic string, n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.settled[topic] += n
+	c.notifyLocked()
+}
+
+func (c *Client) takeFailureLocked(topic string) error {
+	queue := c.failures[topic]
+	if len(queue) == 0 {
+		return nil
+	}
+	c.failures[topic] = queue[1:]
+	return queue[0]
+}
+
+func (c *Client) appendLocked(topic string, key, value []byte, headers []sarama.RecordHeader) *Record {
+	r := &Record{
+		Topic:     topic,
+		Offset:    int64(len(c.logs[topic])),
+		Key:       key,
+		Value:     value,
+		Headers:   headers,
+		Timestamp: time.Now(),
+	}
+	c.logs[topic] = append(c.logs[topic], r)
+	c.notifyLocked()
+	return r
+}
+
+// notifyLocked wakes every waiter; callers must hold c.mu
+func (c *Client) notifyLocked() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+func toConsumerMessage(r *Record) *sarama.ConsumerMessage {
+	headers := make([]*sarama.RecordHeader, len(r.Headers))
+	for i := range r.Headers {
+		h := r.Headers[i]
+		headers[i] = &h
+	}
+	return &sarama.ConsumerMessage{
+		Topic:     r.Topic,
+		Partition: r.Partition,
+		Offset:    r.Offset,
+		Key:       r.Key,
+		Value:     r.Value,
+		Headers:   headers,
+		Timestamp: r.Timestamp,
+	}
+}
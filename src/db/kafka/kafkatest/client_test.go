@@ -0,0 +1,83 @@
+package kafkatest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ajinx1/go-storage-config/src/db/kafka"
+	"github.com/IBM/sarama"
+)
+
+func TestAsyncFailureIsDeadLettered(t *testing.T) {
+	c := NewClient(kafka.KafkaConfig{})
+	errDeliver := errors.New("leader not available")
+	c.FailPublish("orders", errDeliver, 1)
+
+	var failed error
+	p, _ := c.NewAsyncPublisher(kafka.AsyncCallbacks{
+		OnError: func(_ *sarama.ProducerMessage, err error) { failed = err },
+	})
+	headers := []sarama.RecordHeader{{Key: []byte("trace-id"), Value: []byte("t-1")}}
+	if err := p.PublishWithHeaders(context.Background(), "orders", []byte("k"), []byte("v"), headers); err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+	if !errors.Is(failed, errDeliver) {
+		t.Errorf("OnError got %v, want %v", failed, errDeliver)
+	}
+
+	letters, err := c.ListDeadLetters(context.Background(), kafka.DeadLetterFilter{})
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(letters) != 1 {
+		t.Fatalf("listed %d dead letters, want 1", len(letters))
+	}
+	dl := letters[0]
+	if dl.OriginalTopic != "orders" || dl.Error != errDeliver.Error() {
+		t.Errorf("dead letter parsed as %+v", dl)
+	}
+	if len(dl.Headers) != 1 || string(dl.Headers[0].Key) != "trace-id" {
+		t.Errorf("original headers %v, want trace-id only", dl.Headers)
+	}
+}
+
+func TestRedriveTwiceRepublishesOnce(t *testing.T) {
+	c := NewClient(kafka.KafkaConfig{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c.Inject("orders", []byte("k"), []byte("v"))
+	subCtx, stop := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.SubscribeMessages(subCtx, []string{"orders"}, func(context.Context, *kafka.Message) error {
+			return errors.New("handler failed")
+		})
+	}()
+	if err := c.WaitConsumed(ctx, "orders", 1); err != nil {
+		t.Fatalf("message was not consumed: %v", err)
+	}
+	stop()
+	<-done
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.RedriveDeadLetters(ctx, kafka.DeadLetterFilter{}, 0); err != nil {
+			t.Fatalf("redrive %d failed: %v", i+1, err)
+		}
+	}
+	if n := len(c.Published("orders")); n != 1 {
+		t.Errorf("republished %d times, want once", n)
+	}
+
+	pending, _ := c.ListDeadLetters(ctx, kafka.DeadLetterFilter{})
+	if len(pending) != 0 {
+		t.Errorf("listed %d pending dead letters, want none", len(pending))
+	}
+	all, _ := c.ListDeadLetters(ctx, kafka.DeadLetterFilter{IncludeRedriven: true})
+	if len(all) != 1 || !all[0].Redriven {
+		t.Errorf("listed %+v, want one redriven dead letter", all)
+	}
+}
@@ -0,0 +1,63 @@
+package kafkatest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Ajinx1/go-storage-config/src/db/kafka"
+	"github.com/IBM/sarama"
+)
+
+// ListDeadLetters returns the DLQ records matching filter, skipping redriven
+// ones the same way the real client does
+func (c *Client) ListDeadLetters(ctx context.Context, filter kafka.DeadLetterFilter) ([]kafka.DeadLetter, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var msgs []*sarama.ConsumerMessage
+	for _, r := range c.logs[c.config.DeadLetterTopic] {
+		msgs = append(msgs, toConsumerMessage(r))
+	}
+	return kafka.SelectDeadLetters(msgs, filter), nil
+}
+
+// Redrive appends letters to their original topics with a redriven-from
+// header and a redrive marker to the DLQ topic. The rate is ignored.
+func (c *Client) Redrive(ctx context.Context, letters []kafka.DeadLetter, _ int) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sent := 0
+	for _, dl := range letters {
+		if dl.OriginalTopic == "" {
+			return sent, fmt.Errorf("dead letter %s has no original topic", dl.ID)
+		}
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+		if err := c.takeFailureLocked(dl.OriginalTopic); err != nil {
+			return sent, fmt.Errorf("failed to redrive dead letter %s: %w", dl.ID, err)
+		}
+
+		headers := append([]sarama.RecordHeader{}, dl.Headers...)
+		headers = append(headers, sarama.RecordHeader{Key: []byte("redriven-from"), Value: []byte(dl.ID)})
+		c.published = append(c.published, c.appendLocked(dl.OriginalTopic, dl.Key, dl.Value, headers))
+		c.appendLocked(c.config.DeadLetterTopic, dl.Key, nil, []sarama.RecordHeader{
+			{Key: []byte(kafka.HeaderRedriven), Value: []byte(dl.ID)},
+		})
+		sent++
+	}
+	return sent, nil
+}
+
+func (c *Client) RedriveDeadLetters(ctx context.Context, filter kafka.DeadLetterFilter, ratePerSecond int) (int, error) {
+	letters, err := c.ListDeadLetters(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return c.Redrive(ctx, letters, ratePerSecond)
+}
@@ -0,0 +1,93 @@
+package kafkatest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Ajinx1/go-storage-config/src/db/kafka"
+	"github.com/IBM/sarama"
+)
+
+// Transaction buffers the messages published through tx and appends them all
+// when fn returns nil, or none when it fails. Like the real client it requires
+// Idempotent and TransactionalID.
+func (c *Client) Transaction(ctx context.Context, fn func(tx *kafka.Tx) error) error {
+	if !c.transactional() {
+		return kafka.ErrNotTransactional
+	}
+	return c.runTxn(ctx, fn)
+}
+
+// SubscribeTransactional consumes topics like SubscribeMessages, committing
+// handler's publishes only when it succeeds. Messages that exhaust their
+// retries are dead-lettered.
+func (c *Client) SubscribeTransactional(ctx context.Context, topics []string, handler kafka.TransformHandler) error {
+	if !c.transactional() {
+		return kafka.ErrNotTransactional
+	}
+	defer c.join(topics)()
+
+	for {
+		batch, err := c.next(ctx, topics, 1)
+		if err != nil {
+			return err
+		}
+		if batch == nil {
+			return nil
+		}
+		msg := batch[0]
+
+		attempts, herr := c.retry(func() error {
+			return c.runTxn(ctx, func(tx *kafka.Tx) error {
+				return handler(ctx, msg, tx)
+			})
+		})
+		if herr != nil {
+			c.deadLetter(msg, attempts, herr)
+		}
+		c.settle(msg.Topic, 1)
+	}
+}
+
+func (c *Client) runTxn(ctx context.Context, fn func(tx *kafka.Tx) error) error {
+	var pending []*sarama.ProducerMessage
+	tx := kafka.NewTx(ctx, func(msg *sarama.ProducerMessage) error {
+		pending = append(pending, msg)
+		return nil
+	})
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.isClosed {
+		return ErrClosed
+	}
+	for _, msg := range pending {
+		key, value, err := encodeMessage(msg)
+		if err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		c.published = append(c.published, c.appendLocked(msg.Topic, key, value, msg.Headers))
+	}
+	return nil
+}
+
+func (c *Client) transactional() bool {
+	return c.config.Idempotent && c.config.TransactionalID != ""
+}
+
+func encodeMessage(msg *sarama.ProducerMessage) (key, value []byte, err error) {
+	if msg.Key != nil {
+		if key, err = msg.Key.Encode(); err != nil {
+			return nil, nil, err
+		}
+	}
+	if msg.Value != nil {
+		if value, err = msg.Value.Encode(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return key, value, nil
+}
@@ -0,0 +1,108 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Message is a consumed Kafka record with all of its metadata
+type Message struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   []*sarama.RecordHeader
+	Timestamp time.Time
+	Raw       *sarama.ConsumerMessage
+
+	ack     func()
+	ackOnce sync.Once
+}
+
+// Handler processes a consumed message
+type Handler func(ctx context.Context, msg *Message) error
+
+// ConsumerMiddleware wraps a Handler; returning without calling next skips the message
+type ConsumerMiddleware func(next Handler) Handler
+
+func NewMessage(msg *sarama.ConsumerMessage) *Message {
+	return &Message{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Key:       msg.Key,
+		Value:     msg.Value,
+		Headers:   msg.Headers,
+		Timestamp: msg.Timestamp,
+		Raw:       msg,
+	}
+}
+
+// Ack marks the message as processed and, in manual commit mode, commits its
+// offset. It is a no-op outside of a consumer session and safe to call twice.
+func (m *Message) Ack() {
+	if m.ack != nil {
+		m.ackOnce.Do(m.ack)
+	}
+}
+
+// Header returns the value of the first header named key, or nil
+func (m *Message) Header(key string) []byte {
+	for _, h := range m.Headers {
+		if h != nil && string(h.Key) == key {
+			return h.Value
+		}
+	}
+	return nil
+}
+
+// Chain applies middlewares to h so that middlewares[0] runs first
+func Chain(h Handler, middlewares ...ConsumerMiddleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// AdaptMiddleware runs a topic/value Middleware before the rest of the chain
+func AdaptMiddleware(mw Middleware) ConsumerMiddleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			if err := mw(ctx, msg.Topic, msg.Value); err != nil {
+				return fmt.Errorf("middleware failed: %w", err)
+			}
+			return next(ctx, msg)
+		}
+	}
+}
+
+// FilterMiddleware skips (and commits) messages for which keep returns false
+func FilterMiddleware(keep func(*Message) bool) ConsumerMiddleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			if !keep(msg) {
+				return nil
+			}
+			return next(ctx, msg)
+		}
+	}
+}
+
+// LoggingMiddleware logs each message's coordinates and handling time
+func LoggingMiddleware() ConsumerMiddleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			start := time.Now()
+			err := next(ctx, msg)
+			log.Printf("Processed message %s/%d/%d in %v (err: %v)",
+				msg.Topic, msg.Partition, msg.Offset, time.Since(start), err)
+			return err
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+var ErrGroupActive = errors.New("consumer group has active members; stop its consumers before resetting offsets")
+
+// OffsetReset describes where a consumer group should resume. Exactly one of
+// Earliest, Latest, Timestamp or Offsets should be set.
+type OffsetReset struct {
+	Earliest  bool
+	Latest    bool
+	Timestamp time.Time       // First offset at or after this time; latest when none
+	Offsets   map[int32]int64 // Explicit offset per partition; other partitions are left alone
+}
+
+// ResetOffsets moves groupID's committed offsets on topic for replaying or
+// skipping messages. The group must have no active members.
+func (c *Client) ResetOffsets(groupID, topic string, reset OffsetReset) error {
+	admin, err := c.conn.ClusterAdmin()
+	if err != nil {
+		return err
+	}
+
+	groups, err := admin.DescribeConsumerGroups([]string{groupID})
+	if err != nil {
+		return fmt.Errorf("failed to describe consumer group %s: %w", groupID, err)
+	}
+	for _, g := range groups {
+		if len(g.Members) > 0 {
+			return fmt.Errorf("%w: %s", ErrGroupActive, groupID)
+		}
+	}
+
+	client := c.conn.Client
+
+	targets, err := resolveOffsets(client, topic, reset)
+	if err != nil {
+		return err
+	}
+
+	return commitOffsets(client, groupID, topic, targets)
+}
+
+// commitOffsets writes targets as groupID's committed offsets in a single
+// OffsetCommit request to the group coordinator. Unlike an offset manager it
+// can move offsets backwards and forwards and needs no group membership.
+func commitOffsets(client sarama.Client, groupID, topic string, targets map[int32]int64) error {
+	coordinator, err := client.Coordinator(groupID)
+	if err != nil {
+		return fmt.Errorf("failed to find coordinator for group %s: %w", groupID, err)
+	}
+
+	req := newOffsetCommitRequest(client.Config().Version, groupID)
+	timestamp := int64(0)
+	if req.Version == 1 {
+		timestamp = sarama.ReceiveTime
+	}
+	for partition, offset := range targets {
+		req.AddBlock(topic, partition, offset, timestamp, "")
+	}
+
+	resp, err := coordinator.CommitOffset(req)
+	if err != nil {
+		return fmt.Errorf("failed to commit offsets for group %s: %w", groupID, err)
+	}
+	for partition, kerr := range resp.Errors[topic] {
+		if kerr != sarama.ErrNoError {
+			return fmt.Errorf("failed to commit offset for %s/%d: %w", topic, partition, kerr)
+		}
+	}
+	return nil
+}
+
+// newOffsetCommitRequest picks the request version the same way sarama's
+// offset manager does, committing outside any group generation
+func newOffsetCommitRequest(version sarama.KafkaVersion, groupID string) *sarama.OffsetCommitRequest {
+	req := &sarama.OffsetCommitRequest{
+		Version:                 1,
+		ConsumerGroup:           groupID,
+		ConsumerGroupGeneration: sarama.GroupGenerationUndefined,
+	}
+	switch {
+	case version.IsAtLeast(sarama.V2_3_0_0):
+		req.Version = 7
+	case version.IsAtLeast(sarama.V2_1_0_0):
+		req.Version = 6
+	case version.IsAtLeast(sarama.V2_0_0_0):
+		req.Version = 4
+	case version.IsAtLeast(sarama.V0_11_0_0):
+		req.Version = 3
+	case version.IsAtLeast(sarama.V0_9_0_0):
+		req.Version = 2
+	}
+	if req.Version >= 2 && req.Version < 5 {
+		req.RetentionTime = -1
+	}
+	return req
+}
+
+// resolveOffsets turns an OffsetReset into a concrete offset per partition
+func resolveOffsets(client sarama.Client, topic string, reset OffsetReset) (map[int32]int64, error) {
+	if len(reset.Offsets) > 0 {
+		return reset.Offsets, nil
+	}
+
+	var at int64
+	switch {
+	case reset.Earliest:
+		at = sarama.OffsetOldest
+	case reset.Latest:
+		at = sarama.OffsetNewest
+	case !reset.Timestamp.IsZero():
+		at = reset.Timestamp.UnixMilli()
+	default:
+		return nil, fmt.Errorf("offset reset for topic %s has no target", topic)
+	}
+
+	partitions, err := client.Partitions(topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions of %s: %w", topic, err)
+	}
+
+	targets := make(map[int32]int64, len(partitions))
+	for _, p := range partitions {
+		offset, err := client.GetOffset(topic, p, at)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up offset for %s/%d: %w", topic, p, err)
+		}
+		if offset == -1 {
+			// No message at or after the timestamp
+			if offset, err = client.GetOffset(topic, p, sarama.OffsetNewest); err != nil {
+				return nil, fmt.Errorf("failed to look up offset for %s/%d: %w", topic, p, err)
+			}
+		}
+		targets[p] = offset
+	}
+	return targets, nil
+}
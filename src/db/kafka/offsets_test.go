@@ -0,0 +1,153 @@
+package kafka
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+const (
+	testGroup = "replay-group"
+	testTopic = "orders"
+)
+
+// newOffsetTestClient returns a Client backed by a mock broker with two
+// partitions of testTopic holding offsets 5..100 and 7..200
+func newOffsetTestClient(t *testing.T, members map[string]*sarama.GroupMemberDescription) (*Client, *sarama.MockBroker) {
+	t.Helper()
+
+	broker := sarama.NewMockBroker(t, 1)
+	t.Cleanup(broker.Close)
+
+	ts := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetController(broker.BrokerID()).
+			SetLeader(testTopic, 0, broker.BrokerID()).
+			SetLeader(testTopic, 1, broker.BrokerID()),
+		"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+			SetCoordinator(sarama.CoordinatorGroup, testGroup, broker),
+		"DescribeGroupsRequest": sarama.NewMockDescribeGroupsResponse(t).
+			AddGroupDescription(testGroup, &sarama.GroupDescription{
+				GroupId: testGroup,
+				State:   "Empty",
+				Members: members,
+			}),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset(testTopic, 0, sarama.OffsetOldest, 5).
+			SetOffset(testTopic, 0, sarama.OffsetNewest, 100).
+			SetOffset(testTopic, 0, ts, 42).
+			SetOffset(testTopic, 1, sarama.OffsetOldest, 7).
+			SetOffset(testTopic, 1, sarama.OffsetNewest, 200).
+			SetOffset(testTopic, 1, ts, -1),
+		"OffsetCommitRequest": sarama.NewMockOffsetCommitResponse(t),
+	})
+
+	cfg := sarama.NewConfig()
+	cfg.ApiVersionsRequest = false
+	cfg.Metadata.Retry.Max = 0
+	client, err := sarama.NewClient([]string{broker.Addr()}, cfg)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return &Client{conn: &KafkaConn{Client: client}, config: DefaultConfig()}, broker
+}
+
+// committedOffsets returns the offsets of the last OffsetCommit request seen by broker
+func committedOffsets(t *testing.T, broker *sarama.MockBroker) map[int32]int64 {
+	t.Helper()
+
+	var req *sarama.OffsetCommitRequest
+	for _, rr := range broker.History() {
+		if r, ok := rr.Request.(*sarama.OffsetCommitRequest); ok {
+			req = r
+		}
+	}
+	if req == nil {
+		t.Fatal("no offset commit request was sent")
+	}
+	if req.ConsumerGroup != testGroup {
+		t.Errorf("committed for group %q, want %q", req.ConsumerGroup, testGroup)
+	}
+
+	offsets := make(map[int32]int64)
+	for _, p := range []int32{0, 1} {
+		if offset, _, err := req.Offset(testTopic, p); err == nil {
+			offsets[p] = offset
+		}
+	}
+	return offsets
+}
+
+func TestResetOffsets(t *testing.T) {
+	tests := []struct {
+		name  string
+		reset OffsetReset
+		want  map[int32]int64
+	}{
+		{
+			name:  "earliest",
+			reset: OffsetReset{Earliest: true},
+			want:  map[int32]int64{0: 5, 1: 7},
+		},
+		{
+			name:  "latest moves forward",
+			reset: OffsetReset{Latest: true},
+			want:  map[int32]int64{0: 100, 1: 200},
+		},
+		{
+			name:  "timestamp falls back to latest without later messages",
+			reset: OffsetReset{Timestamp: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+			want:  map[int32]int64{0: 42, 1: 200},
+		},
+		{
+			name:  "explicit offsets only touch listed partitions",
+			reset: OffsetReset{Offsets: map[int32]int64{1: 150}},
+			want:  map[int32]int64{1: 150},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, broker := newOffsetTestClient(t, nil)
+
+			done := make(chan error, 1)
+			go func() { done <- client.ResetOffsets(testGroup, testTopic, tt.reset) }()
+
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatalf("ResetOffsets: %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("ResetOffsets did not return")
+			}
+
+			got := committedOffsets(t, broker)
+			if len(got) != len(tt.want) {
+				t.Fatalf("committed %v, want %v", got, tt.want)
+			}
+			for p, offset := range tt.want {
+				if got[p] != offset {
+					t.Errorf("partition %d committed %d, want %d", p, got[p], offset)
+				}
+			}
+		})
+	}
+}
+
+func TestResetOffsetsRejectsActiveGroup(t *testing.T) {
+	client, _ := newOffsetTestClient(t, map[string]*sarama.GroupMemberDescription{
+		"member-1": {ClientId: "consumer", ClientHost: "/127.0.0.1"},
+	})
+
+	err := client.ResetOffsets(testGroup, testTopic, OffsetReset{Earliest: true})
+	if !errors.Is(err, ErrGroupActive) {
+		t.Fatalf("got %v, want ErrGroupActive", err)
+	}
+}
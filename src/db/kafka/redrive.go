@@ -0,0 +1,289 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// DeadLetter is a message read back from the DLQ topic with its failure metadata
+type DeadLetter struct {
+	ID                string // DLQ partition:offset
+	OriginalTopic     string
+	OriginalPartition int32
+	OriginalOffset    int64
+	Error             string
+	Attempts          int
+	Timestamp         time.Time
+	Key               []byte
+	Value             []byte
+	Headers           []sarama.RecordHeader // Headers of the original message
+	Redriven          bool                  // A redrive marker for it follows in the DLQ
+}
+
+// DeadLetterFilter selects dead letters; zero fields match everything
+type DeadLetterFilter struct {
+	OriginalTopic string
+	Since         time.Time
+	Until         time.Time
+	IDs           []string // Restrict to these DLQ positions (partition:offset)
+	Limit         int
+	// IncludeRedriven also selects dead letters that were already redriven,
+	// which are skipped by default so a second redrive does not repeat them
+	IncludeRedriven bool
+}
+
+// HeaderRedriven marks a DLQ record written by Redrive; its value is the ID
+// of the dead letter that was republished
+const HeaderRedriven = "redriven"
+
+// dlqHeaders are added by the client when dead-lettering and stripped on redrive
+var dlqHeaders = map[string]bool{
+	"original-topic":     true,
+	"original-partition": true,
+	"original-offset":    true,
+	"timestamp":          true,
+	"error":              true,
+	"attempts":           true,
+	"decode-error":       true,
+	HeaderRedriven:       true,
+}
+
+// Match reports whether dl passes the filter
+func (f DeadLetterFilter) Match(dl *DeadLetter) bool {
+	if f.OriginalTopic != "" && dl.OriginalTopic != f.OriginalTopic {
+		return false
+	}
+	if !f.Since.IsZero() && dl.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && dl.Timestamp.After(f.Until) {
+		return false
+	}
+	if len(f.IDs) > 0 {
+		for _, id := range f.IDs {
+			if id == dl.ID {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// ListDeadLetters reads the DLQ topic up to its current end and returns the
+// messages matching filter, ordered by partition and offset. The whole topic
+// is read even with a Limit, since redrive markers can follow in any partition.
+func (c *Client) ListDeadLetters(ctx context.Context, filter DeadLetterFilter) ([]DeadLetter, error) {
+	client := c.conn.Client
+
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	topic := c.config.DeadLetterTopic
+	partitions, err := client.Partitions(topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions of %s: %w", topic, err)
+	}
+
+	var msgs []*sarama.ConsumerMessage
+	for _, p := range partitions {
+		end, err := client.GetOffset(topic, p, sarama.OffsetNewest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up end offset for %s/%d: %w", topic, p, err)
+		}
+		start, err := client.GetOffset(topic, p, sarama.OffsetOldest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up start offset for %s/%d: %w", topic, p, err)
+		}
+		if start >= end {
+			continue
+		}
+
+		found, err := readDeadLetters(ctx, consumer, topic, p, start, end)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, found...)
+	}
+	return SelectDeadLetters(msgs, filter), nil
+}
+
+// SelectDeadLetters parses DLQ records and returns the dead letters matching
+// filter. Redrive markers are dropped, and the dead letters they name are
+// flagged Redriven and left out unless filter.IncludeRedriven is set.
+func SelectDeadLetters(msgs []*sarama.ConsumerMessage, filter DeadLetterFilter) []DeadLetter {
+	redriven := make(map[string]bool)
+	for _, msg := range msgs {
+		if id, ok := redriveMarker(msg); ok {
+			redriven[id] = true
+		}
+	}
+
+	var letters []DeadLetter
+	for _, msg := range msgs {
+		if _, ok := redriveMarker(msg); ok {
+			continue
+		}
+		dl := ParseDeadLetter(msg)
+		dl.Redriven = redriven[dl.ID]
+		if dl.Redriven && !filter.IncludeRedriven {
+			continue
+		}
+		if !filter.Match(&dl) {
+			continue
+		}
+		letters = append(letters, dl)
+		if filter.Limit > 0 && len(letters) >= filter.Limit {
+			break
+		}
+	}
+	return letters
+}
+
+// redriveMarker returns the dead letter ID a redrive marker refers to
+func redriveMarker(msg *sarama.ConsumerMessage) (string, bool) {
+	for _, h := range msg.Headers {
+		if h != nil && string(h.Key) == HeaderRedriven {
+			return string(h.Value), true
+		}
+	}
+	return "", false
+}
+
+// deadLetterReadIdle ends a partition read when nothing arrives for this long.
+// The end offset can point past records that are never delivered, such as
+// transaction markers or aborted and still-open transactional writes.
+const deadLetterReadIdle = 3 * time.Second
+
+func readDeadLetters(ctx context.Context, consumer sarama.Consumer, topic string, partition int32,
+	start, end int64) ([]*sarama.ConsumerMessage, error) {
+
+	pc, err := consumer.ConsumePartition(topic, partition, start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume %s/%d: %w", topic, partition, err)
+	}
+	defer pc.Close()
+
+	idle := time.NewTimer(deadLetterReadIdle)
+	defer idle.Stop()
+
+	var msgs []*sarama.ConsumerMessage
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case err := <-pc.Errors():
+			return nil, fmt.Errorf("failed to read %s/%d: %w", topic, partition, err)
+		case <-idle.C:
+			return msgs, nil
+		case msg := <-pc.Messages():
+			msgs = append(msgs, msg)
+			if msg.Offset >= end-1 {
+				return msgs, nil
+			}
+			idle.Reset(deadLetterReadIdle)
+		}
+	}
+}
+
+// ParseDeadLetter reads the failure metadata the client adds when dead-lettering msg
+func ParseDeadLetter(msg *sarama.ConsumerMessage) DeadLetter {
+	dl := DeadLetter{
+		ID:        fmt.Sprintf("%d:%d", msg.Partition, msg.Offset),
+		Timestamp: msg.Timestamp,
+		Key:       msg.Key,
+		Value:     msg.Value,
+	}
+
+	for _, h := range msg.Headers {
+		if h == nil {
+			continue
+		}
+		value := string(h.Value)
+		switch string(h.Key) {
+		case "original-topic":
+			dl.OriginalTopic = value
+		case "original-partition":
+			p, _ := strconv.ParseInt(value, 10, 32)
+			dl.OriginalPartition = int32(p)
+		case "original-offset":
+			dl.OriginalOffset, _ = strconv.ParseInt(value, 10, 64)
+		case "error":
+			dl.Error = value
+		case "attempts":
+			dl.Attempts, _ = strconv.Atoi(value)
+		}
+		if !dlqHeaders[string(h.Key)] {
+			dl.Headers = append(dl.Headers, *h)
+		}
+	}
+	return dl
+}
+
+// Redrive republishes dead letters to their original topics, sending at most
+// ratePerSecond messages per second (unlimited when zero). It returns how many
+// were republished. Kafka cannot delete the dead letters, so each one stays in
+// the DLQ topic and a redrive marker naming it is appended there; listings and
+// later redrives skip marked dead letters unless IncludeRedriven is set.
+func (c *Client) Redrive(ctx context.Context, letters []DeadLetter, ratePerSecond int) (int, error) {
+	var tick <-chan time.Time
+	if ratePerSecond > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(ratePerSecond))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	sent := 0
+	for _, dl := range letters {
+		if dl.OriginalTopic == "" {
+			return sent, fmt.Errorf("dead letter %s has no original topic", dl.ID)
+		}
+		if tick != nil && sent > 0 {
+			select {
+			case <-ctx.Done():
+				return sent, ctx.Err()
+			case <-tick:
+			}
+		}
+
+		headers := append([]sarama.RecordHeader{}, dl.Headers...)
+		headers = append(headers, sarama.RecordHeader{Key: []byte("redriven-from"), Value: []byte(dl.ID)})
+
+		err := c.send(&sarama.ProducerMessage{
+			Topic:   dl.OriginalTopic,
+			Key:     sarama.ByteEncoder(dl.Key),
+			Value:   sarama.ByteEncoder(dl.Value),
+			Headers: headers,
+		})
+		if err != nil {
+			return sent, fmt.Errorf("failed to redrive dead letter %s: %w", dl.ID, err)
+		}
+		sent++
+
+		err = c.send(&sarama.ProducerMessage{
+			Topic:   c.config.DeadLetterTopic,
+			Key:     sarama.ByteEncoder(dl.Key),
+			Headers: []sarama.RecordHeader{{Key: []byte(HeaderRedriven), Value: []byte(dl.ID)}},
+		})
+		if err != nil {
+			return sent, fmt.Errorf("redrove dead letter %s but failed to mark it: %w", dl.ID, err)
+		}
+	}
+	return sent, nil
+}
+
+// RedriveDeadLetters lists the dead letters matching filter and republishes them
+func (c *Client) RedriveDeadLetters(ctx context.Context, filter DeadLetterFilter, ratePerSecond int) (int, error) {
+	letters, err := c.ListDeadLetters(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return c.Redrive(ctx, letters, ratePerSecond)
+}
@@ -0,0 +1,58 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+func TestSelectDeadLettersSkipsRedriven(t *testing.T) {
+	letter := func(offset int64, topic string) *sarama.ConsumerMessage {
+		return &sarama.ConsumerMessage{Partition: 0, Offset: offset, Headers: []*sarama.RecordHeader{
+			{Key: []byte("original-topic"), Value: []byte(topic)},
+		}}
+	}
+	marker := func(offset int64, id string) *sarama.ConsumerMessage {
+		return &sarama.ConsumerMessage{Partition: 1, Offset: offset, Headers: []*sarama.RecordHeader{
+			{Key: []byte(HeaderRedriven), Value: []byte(id)},
+		}}
+	}
+	msgs := []*sarama.ConsumerMessage{
+		letter(0, "orders"),
+		letter(1, "orders"),
+		letter(2, "payments"),
+		marker(0, "0:0"),
+	}
+
+	tests := []struct {
+		name   string
+		filter DeadLetterFilter
+		want   []string
+	}{
+		{name: "redriven skipped", want: []string{"0:1", "0:2"}},
+		{name: "include redriven", filter: DeadLetterFilter{IncludeRedriven: true}, want: []string{"0:0", "0:1", "0:2"}},
+		{name: "limit counts selected only", filter: DeadLetterFilter{Limit: 1}, want: []string{"0:1"}},
+		{name: "filter by topic", filter: DeadLetterFilter{OriginalTopic: "payments"}, want: []string{"0:2"}},
+		{name: "redriven id", filter: DeadLetterFilter{IDs: []string{"0:0"}}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, dl := range SelectDeadLetters(msgs, tt.filter) {
+				got = append(got, dl.ID)
+				if dl.Redriven != (dl.ID == "0:0") {
+					t.Errorf("%s: Redriven = %t", dl.ID, dl.Redriven)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Ajinx1/go-storage-config/src/db/kafka/schemaregistry"
+	"github.com/hamba/avro/v2"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// SchemaCodec is a Codec backed by a schema registry. Payloads are validated
+// against the subject's schema and framed in the Confluent wire format;
+// incoming payloads are decoded with the schema referenced by their ID.
+type SchemaCodec struct {
+	registry   schemaregistry.Client
+	schemaType string
+	schemaID   int
+	writer     *compiledSchema
+
+	mu      sync.RWMutex
+	readers map[int]*compiledSchema
+}
+
+type compiledSchema struct {
+	schemaType string
+	avro       avro.Schema
+	json       *jsonschema.Schema
+}
+
+// NewSchemaCodec registers schema under subject (after a compatibility check)
+// and returns a codec that writes with it
+func NewSchemaCodec(ctx context.Context, registry schemaregistry.Client, subject, schemaType, schema string) (*SchemaCodec, error) {
+	id, err := registry.Register(ctx, subject, schemaType, schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register schema for subject %s: %w", subject, err)
+	}
+	return newSchemaCodec(registry, &schemaregistry.Schema{ID: id, Subject: subject, Type: schemaType, Schema: schema})
+}
+
+// NewSchemaCodecFromLatest writes with the latest schema registered under subject
+func NewSchemaCodecFromLatest(ctx context.Context, registry schemaregistry.Client, subject string) (*SchemaCodec, error) {
+	latest, err := registry.GetLatest(ctx, subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch latest schema for subject %s: %w", subject, err)
+	}
+	return newSchemaCodec(registry, latest)
+}
+
+func newSchemaCodec(registry schemaregistry.Client, s *schemaregistry.Schema) (*SchemaCodec, error) {
+	writer, err := compileSchema(s)
+	if err != nil {
+		return nil, err
+	}
+	return &SchemaCodec{
+		registry:   registry,
+		schemaType: writer.schemaType,
+		schemaID:   s.ID,
+		writer:     writer,
+		readers:    map[int]*compiledSchema{s.ID: writer},
+	}, nil
+}
+
+func (c *SchemaCodec) ContentType() string {
+	if c.schemaType == schemaregistry.JSONSchema {
+		return "application/schema+json"
+	}
+	return "application/vnd.apache.avro+binary"
+}
+
+func (c *SchemaCodec) Marshal(v any) ([]byte, error) {
+	body, err := c.writer.encode(v)
+	if err != nil {
+		return nil, err
+	}
+	return frameWire(c.schemaID, body), nil
+}
+
+func (c *SchemaCodec) Unmarshal(data []byte, v any) error {
+	schemaID, body, err := unframeWire(data)
+	if err != nil {
+		return err
+	}
+
+	reader, err := c.reader(schemaID)
+	if err != nil {
+		return err
+	}
+	return reader.decode(body, v)
+}
+
+// reader returns the compiled schema for id, fetching it from the registry on first use
+func (c *SchemaCodec) reader(id int) (*compiledSchema, error) {
+	c.mu.RLock()
+	reader, ok := c.readers[id]
+	c.mu.RUnlock()
+	if ok {
+		return reader, nil
+	}
+
+	s, err := c.registry.GetByID(context.Background(), id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch schema %d: %w", id, err)
+	}
+	reader, err = compileSchema(s)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.readers[id] = reader
+	c.mu.Unlock()
+	return reader, nil
+}
+
+func compileSchema(s *schemaregistry.Schema) (*compiledSchema, error) {
+	schemaType := s.Type
+	if schemaType == "" {
+		schemaType = schemaregistry.Avro
+	}
+
+	switch schemaType {
+	case schemaregistry.Avro:
+		parsed, err := avro.Parse(s.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse avro schema %d: %w", s.ID, err)
+		}
+		return &compiledSchema{schemaType: schemaType, avro: parsed}, nil
+	case schemaregistry.JSONSchema:
+		url := fmt.Sprintf("registry://schemas/%d.json", s.ID)
+		compiler := jsonschema.NewCompiler()
+		if err := compiler.AddResource(url, strings.NewReader(s.Schema)); err != nil {
+			return nil, fmt.Errorf("failed to load JSON schema %d: %w", s.ID, err)
+		}
+		compiled, err := compiler.Compile(url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile JSON schema %d: %w", s.ID, err)
+		}
+		return &compiledSchema{schemaType: schemaType, json: compiled}, nil
+	default:
+		return nil, fmt.Errorf("unsupported schema type: %s", schemaType)
+	}
+}
+
+func (s *compiledSchema) encode(v any) ([]byte, error) {
+	if s.avro != nil {
+		return avro.Marshal(s.avro, v)
+	}
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateJSON(body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (s *compiledSchema) decode(body []byte, v any) error {
+	if s.avro != nil {
+		return avro.Unmarshal(s.avro, body, v)
+	}
+
+	if err := s.validateJSON(body); err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+func (s *compiledSchema) validateJSON(body []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return err
+	}
+	if err := s.json.Validate(doc); err != nil {
+		return fmt.Errorf("payload does not match JSON schema: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package schemaregistry
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	Avro       = "AVRO"
+	JSONSchema = "JSON"
+	Protobuf   = "PROTOBUF"
+)
+
+var (
+	ErrSchemaNotFound     = errors.New("schema not found")
+	ErrSubjectNotFound    = errors.New("subject not found")
+	ErrIncompatibleSchema = errors.New("schema is incompatible with the latest registered version")
+)
+
+type Schema struct {
+	ID      int
+	Subject string
+	Version int
+	Type    string // AVRO, JSON or PROTOBUF
+	Schema  string
+}
+
+// Client is the subset of the Confluent schema registry API used by the kafka codecs
+type Client interface {
+	// GetByID returns the schema registered under id
+	GetByID(ctx context.Context, id int) (*Schema, error)
+	// GetLatest returns the latest schema version of subject
+	GetLatest(ctx context.Context, subject string) (*Schema, error)
+	// CheckCompatibility reports whether schema can be registered under subject.
+	// A subject without versions accepts any schema.
+	CheckCompatibility(ctx context.Context, subject, schemaType, schema string) (bool, error)
+	// Register checks compatibility and registers schema under subject, returning its ID.
+	// Registering an already known schema returns the existing ID.
+	Register(ctx context.Context, subject, schemaType, schema string) (int, error)
+}
+
+func normalizeType(schemaType string) string {
+	if schemaType == "" {
+		return Avro
+	}
+	return schemaType
+}
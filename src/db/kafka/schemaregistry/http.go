@@ -0,0 +1,235 @@
+package schemaregistry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const contentType = "application/vnd.schemaregistry.v1+json"
+
+type Config struct {
+	URL            string
+	Username       string
+	Password       string
+	TimeoutSeconds int
+}
+
+// HTTPClient talks to a Confluent-compatible schema registry over REST and
+// caches schemas by ID and registrations by subject
+type HTTPClient struct {
+	baseURL  string
+	username string
+	password string
+	http     *http.Client
+
+	mu       sync.RWMutex
+	byID     map[int]*Schema
+	register map[string]int
+}
+
+func NewHTTPClient(cfg Config) (*HTTPClient, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("schema registry URL is required")
+	}
+	timeout := 10 * time.Second
+	if cfg.TimeoutSeconds > 0 {
+		timeout = time.Duration(cfg.TimeoutSeconds) * time.Second
+	}
+
+	return &HTTPClient{
+		baseURL:  strings.TrimRight(cfg.URL, "/"),
+		username: cfg.Username,
+		password: cfg.Password,
+		http:     &http.Client{Timeout: timeout},
+		byID:     make(map[int]*Schema),
+		register: make(map[string]int),
+	}, nil
+}
+
+type schemaResponse struct {
+	Subject    string `json:"subject"`
+	ID         int    `json:"id"`
+	Version    int    `json:"version"`
+	SchemaType string `json:"schemaType"`
+	Schema     string `json:"schema"`
+}
+
+type schemaRequest struct {
+	Schema     string `json:"schema"`
+	SchemaType string `json:"schemaType,omitempty"`
+}
+
+type registryError struct {
+	ErrorCode int    `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+func (c *HTTPClient) GetByID(ctx context.Context, id int) (*Schema, error) {
+	c.mu.RLock()
+	s, ok := c.byID[id]
+	c.mu.RUnlock()
+	if ok {
+		return s, nil
+	}
+
+	var resp schemaResponse
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/schemas/ids/%d", id), nil, &resp); err != nil {
+		return nil, err
+	}
+
+	s = &Schema{ID: id, Type: normalizeType(resp.SchemaType), Schema: resp.Schema}
+	c.mu.Lock()
+	c.byID[id] = s
+	c.mu.Unlock()
+	return s, nil
+}
+
+func (c *HTTPClient) GetLatest(ctx context.Context, subject string) (*Schema, error) {
+	var resp schemaResponse
+	path := "/subjects/" + url.PathEscape(subject) + "/versions/latest"
+	if err := c.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+
+	s := &Schema{
+		ID:      resp.ID,
+		Subject: resp.Subject,
+		Version: resp.Version,
+		Type:    normalizeType(resp.SchemaType),
+		Schema:  resp.Schema,
+	}
+	c.mu.Lock()
+	c.byID[s.ID] = s
+	c.mu.Unlock()
+	return s, nil
+}
+
+func (c *HTTPClient) CheckCompatibility(ctx context.Context, subject, schemaType, schema string) (bool, error) {
+	var resp struct {
+		IsCompatible bool `json:"is_compatible"`
+	}
+	path := "/compatibility/subjects/" + url.PathEscape(subject) + "/versions/latest"
+	err := c.do(ctx, http.MethodPost, path, newSchemaRequest(schemaType, schema), &resp)
+	if err != nil {
+		if isNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	return resp.IsCompatible, nil
+}
+
+func (c *HTTPClient) Register(ctx context.Context, subject, schemaType, schema string) (int, error) {
+	key := registrationKey(subject, schemaType, schema)
+	c.mu.RLock()
+	id, ok := c.register[key]
+	c.mu.RUnlock()
+	if ok {
+		return id, nil
+	}
+
+	compatible, err := c.CheckCompatibility(ctx, subject, schemaType, schema)
+	if err != nil {
+		return 0, err
+	}
+	if !compatible {
+		return 0, fmt.Errorf("%w: subject %s", ErrIncompatibleSchema, subject)
+	}
+
+	var resp struct {
+		ID int `json:"id"`
+	}
+	path := "/subjects/" + url.PathEscape(subject) + "/versions"
+	if err := c.do(ctx, http.MethodPost, path, newSchemaRequest(schemaType, schema), &resp); err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	c.register[key] = resp.ID
+	c.byID[resp.ID] = &Schema{ID: resp.ID, Subject: subject, Type: normalizeType(schemaType), Schema: schema}
+	c.mu.Unlock()
+	return resp.ID, nil
+}
+
+func (c *HTTPClient) do(ctx context.Context, method, path string, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", contentType)
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("schema registry request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var regErr registryError
+		_ = json.NewDecoder(resp.Body).Decode(&regErr)
+		return &statusError{status: resp.StatusCode, code: regErr.ErrorCode, message: regErr.Message}
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+type statusError struct {
+	status  int
+	code    int
+	message string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("schema registry returned %d (code %d): %s", e.status, e.code, e.message)
+}
+
+// Unwrap maps the registry's not-found codes onto the package errors
+func (e *statusError) Unwrap() error {
+	switch e.code {
+	case 40401:
+		return ErrSubjectNotFound
+	case 40402, 40403:
+		return ErrSchemaNotFound
+	}
+	return nil
+}
+
+func isNotFound(err error) bool {
+	se, ok := err.(*statusError)
+	return ok && se.status == http.StatusNotFound
+}
+
+func newSchemaRequest(schemaType, schema string) schemaRequest {
+	req := schemaRequest{Schema: schema}
+	// The registry treats a missing schemaType as AVRO
+	if t := normalizeType(schemaType); t != Avro {
+		req.SchemaType = t
+	}
+	return req
+}
+
+func registrationKey(subject, schemaType, schema string) string {
+	return subject + "\x00" + normalizeType(schemaType) + "\x00" + schema
+}
@@ -0,0 +1,110 @@
+package schemaregistry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// CompatibilityFunc decides whether candidate may follow latest under the same subject
+type CompatibilityFunc func(latest, candidate *Schema) bool
+
+// MemoryClient is an in-process registry for tests. Schemas are compatible
+// unless a Compatibility function says otherwise.
+type MemoryClient struct {
+	Compatibility CompatibilityFunc
+
+	mu       sync.RWMutex
+	nextID   int
+	byID     map[int]*Schema
+	subjects map[string][]*Schema
+}
+
+func NewMemoryClient() *MemoryClient {
+	return &MemoryClient{
+		nextID:   1,
+		byID:     make(map[int]*Schema),
+		subjects: make(map[string][]*Schema),
+	}
+}
+
+func (m *MemoryClient) GetByID(_ context.Context, id int) (*Schema, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.byID[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: id %d", ErrSchemaNotFound, id)
+	}
+	return s, nil
+}
+
+func (m *MemoryClient) GetLatest(_ context.Context, subject string) (*Schema, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.latest(subject)
+}
+
+func (m *MemoryClient) CheckCompatibility(_ context.Context, subject, schemaType, schema string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.compatible(subject, schemaType, schema), nil
+}
+
+func (m *MemoryClient) Register(_ context.Context, subject, schemaType, schema string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	schemaType = normalizeType(schemaType)
+	for _, s := range m.subjects[subject] {
+		if s.Type == schemaType && s.Schema == schema {
+			return s.ID, nil
+		}
+	}
+
+	if !m.compatible(subject, schemaType, schema) {
+		return 0, fmt.Errorf("%w: subject %s", ErrIncompatibleSchema, subject)
+	}
+
+	id := m.idFor(schemaType, schema)
+	s := &Schema{
+		ID:      id,
+		Subject: subject,
+		Version: len(m.subjects[subject]) + 1,
+		Type:    schemaType,
+		Schema:  schema,
+	}
+	m.subjects[subject] = append(m.subjects[subject], s)
+	if _, ok := m.byID[id]; !ok {
+		m.byID[id] = s
+	}
+	return id, nil
+}
+
+// idFor reuses the ID of an identical schema under another subject, as the real registry does
+func (m *MemoryClient) idFor(schemaType, schema string) int {
+	for id, s := range m.byID {
+		if s.Type == schemaType && s.Schema == schema {
+			return id
+		}
+	}
+	id := m.nextID
+	m.nextID++
+	return id
+}
+
+func (m *MemoryClient) latest(subject string) (*Schema, error) {
+	versions := m.subjects[subject]
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrSubjectNotFound, subject)
+	}
+	return versions[len(versions)-1], nil
+}
+
+func (m *MemoryClient) compatible(subject, schemaType, schema string) bool {
+	latest, err := m.latest(subject)
+	if err != nil || m.Compatibility == nil {
+		return true
+	}
+	return m.Compatibility(latest, &Schema{Subject: subject, Type: normalizeType(schemaType), Schema: schema})
+}
@@ -0,0 +1,179 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/IBM/sarama"
+)
+
+var ErrNotTransactional = errors.New("kafka producer is not transactional; set Idempotent and TransactionalID")
+
+// Tx publishes messages inside a producer transaction
+type Tx struct {
+	ctx      context.Context
+	producer sarama.SyncProducer
+	sendFunc func(*sarama.ProducerMessage) error // Set by NewTx instead of producer
+}
+
+// NewTx creates a Tx that hands every message to send. It lets other API
+// implementations, such as kafkatest.Client, buffer messages until commit.
+func NewTx(ctx context.Context, send func(*sarama.ProducerMessage) error) *Tx {
+	return &Tx{ctx: ctx, sendFunc: send}
+}
+
+// TransformHandler processes a consumed message and publishes its results through tx
+type TransformHandler func(ctx context.Context, msg *sarama.ConsumerMessage, tx *Tx) error
+
+// Publish adds a message to the transaction; it becomes visible to
+// read_committed consumers only when the transaction commits
+func (tx *Tx) Publish(topic string, key, value []byte, headers ...sarama.RecordHeader) error {
+	return tx.send(&sarama.ProducerMessage{
+		Topic:   topic,
+		Key:     sarama.ByteEncoder(key),
+		Value:   sarama.ByteEncoder(value),
+		Headers: headers,
+	})
+}
+
+func (tx *Tx) send(msg *sarama.ProducerMessage) error {
+	if err := tx.ctx.Err(); err != nil {
+		return err
+	}
+	if tx.sendFunc != nil {
+		return tx.sendFunc(msg)
+	}
+	_, _, err := tx.producer.SendMessage(msg)
+	return err
+}
+
+// Transaction runs fn inside a producer transaction. Every message published
+// through tx is committed atomically when fn returns nil and aborted otherwise.
+func (c *Client) Transaction(ctx context.Context, fn func(tx *Tx) error) error {
+	if !c.transactional() {
+		return ErrNotTransactional
+	}
+
+	c.txnMu.Lock()
+	defer c.txnMu.Unlock()
+	return c.runTxn(ctx, fn)
+}
+
+// runTxn begins, runs and commits a transaction; callers must hold c.txnMu
+func (c *Client) runTxn(ctx context.Context, fn func(tx *Tx) error) error {
+	producer, err := c.conn.SyncProducer()
+	if err != nil {
+		return err
+	}
+	if err := producer.BeginTxn(); err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(&Tx{ctx: ctx, producer: producer}); err != nil {
+		c.abortTxn()
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		c.abortTxn()
+		return err
+	}
+
+	if err := producer.CommitTxn(); err != nil {
+		if producer.TxnStatus()&sarama.ProducerTxnFlagAbortableError != 0 {
+			c.abortTxn()
+		}
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) abortTxn() {
+	producer, err := c.conn.SyncProducer()
+	if err != nil {
+		log.Printf("Failed to abort transaction: %v", err)
+		return
+	}
+	if err := producer.AbortTxn(); err != nil {
+		log.Printf("Failed to abort transaction: %v", err)
+	}
+}
+
+// transactional reports whether the producer is configured for transactions
+func (c *Client) transactional() bool {
+	return c.config.Idempotent && c.config.TransactionalID != ""
+}
+
+// SubscribeTransactional consumes topics in consume-transform-produce mode.
+// For every message, handler's publishes and the consumer offset are
+// committed in one transaction, so each input is reflected exactly once
+// downstream. Messages that exhaust their retries are dead-lettered the same way.
+// It consumes through a separate group member with auto-commit disabled, so
+// the transaction is the only path that commits its offsets.
+func (c *Client) SubscribeTransactional(ctx context.Context, topics []string, handler TransformHandler) error {
+	if !c.transactional() {
+		return ErrNotTransactional
+	}
+	return c.consumeGroup(ctx, c.conn.TxnConsumerGroup, topics, &txnConsumerGroupHandler{client: c, handler: handler})
+}
+
+// txnConsumerGroupHandler commits consumer offsets through the producer transaction
+type txnConsumerGroupHandler struct {
+	client  *Client
+	handler TransformHandler
+}
+
+func (h *txnConsumerGroupHandler) Setup(_ sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (h *txnConsumerGroupHandler) Cleanup(_ sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (h *txnConsumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	ctx := session.Context()
+	c := h.client
+
+	for msg := range claim.Messages() {
+		attempts, err := c.retryMessage(ctx, msg, func() error {
+			return h.transform(ctx, msg)
+		})
+		if err == nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		dlqErr := h.commit(ctx, msg, func(tx *Tx) error {
+			return tx.send(c.dlqMessage(msg.Topic, msg.Key, msg.Value, FailureHeaders(msg, attempts, err)...))
+		})
+		if dlqErr != nil {
+			log.Printf("Failed to dead-letter message %s/%d/%d: %v", msg.Topic, msg.Partition, msg.Offset, dlqErr)
+			return dlqErr
+		}
+	}
+	return nil
+}
+
+func (h *txnConsumerGroupHandler) transform(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	return h.commit(ctx, msg, func(tx *Tx) error {
+		return h.handler(ctx, msg, tx)
+	})
+}
+
+// commit runs fn and adds the message's offset to the same transaction
+func (h *txnConsumerGroupHandler) commit(ctx context.Context, msg *sarama.ConsumerMessage, fn func(tx *Tx) error) error {
+	c := h.client
+	c.txnMu.Lock()
+	defer c.txnMu.Unlock()
+
+	return c.runTxn(ctx, func(tx *Tx) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return tx.producer.AddMessageToTxn(msg, c.config.GroupID, nil)
+	})
+}
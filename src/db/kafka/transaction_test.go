@@ -0,0 +1,27 @@
+package kafka
+
+import "testing"
+
+func TestTxnConsumerConfigDisablesAutoCommit(t *testing.T) {
+	config := DefaultConfig()
+	config.ManualCommit = false
+
+	base, err := newSaramaConfig(config)
+	if err != nil {
+		t.Fatalf("failed to build config: %v", err)
+	}
+	if !base.Consumer.Offsets.AutoCommit.Enable {
+		t.Fatal("base config should auto-commit")
+	}
+
+	cfg := txnConsumerConfig(base)
+	if cfg.Consumer.Offsets.AutoCommit.Enable {
+		t.Error("transactional consumer config auto-commits offsets")
+	}
+	if !base.Consumer.Offsets.AutoCommit.Enable {
+		t.Error("base config was modified")
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("transactional consumer config is invalid: %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/IBM/sarama"
+)
+
+// PublishTyped encodes value with codec and publishes it with a content-type header
+func PublishTyped[T any](ctx context.Context, c API, codec Codec, topic string, key []byte, value T, middlewares ...Middleware) error {
+	data, err := codec.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode message for topic %s: %w", topic, err)
+	}
+
+	headers := []sarama.RecordHeader{
+		{Key: []byte("content-type"), Value: []byte(codec.ContentType())},
+	}
+	return c.PublishWithHeaders(ctx, topic, key, data, headers, middlewares...)
+}
+
+// SubscribeTyped decodes every message with codec before calling handler.
+// Messages that cannot be decoded are sent to the DLQ with a decode-error
+// header instead of being retried.
+func SubscribeTyped[T any](ctx context.Context, c API, codec Codec, topics []string,
+	handler func(context.Context, T, *sarama.ConsumerMessage) error, middlewares ...Middleware) error {
+
+	return c.Subscribe(ctx, topics, func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+		var value T
+		if err := codec.Unmarshal(msg.Value, &value); err != nil {
+			log.Printf("Failed to decode message %s/%d/%d: %v", msg.Topic, msg.Partition, msg.Offset, err)
+			return c.DeadLetter(ctx, msg, 1, err, sarama.RecordHeader{Key: []byte("decode-error"), Value: []byte(err.Error())})
+		}
+		return handler(ctx, value, msg)
+	}, middlewares...)
+}
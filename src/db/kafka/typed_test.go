@@ -0,0 +1,69 @@
+package kafka_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+
+	"github.com/Ajinx1/go-storage-config/src/db/kafka"
+	"github.com/Ajinx1/go-storage-config/src/db/kafka/kafkatest"
+)
+
+type order struct {
+	ID    string `json:"id"`
+	Total int    `json:"total"`
+}
+
+func header(headers []sarama.RecordHeader, key string) string {
+	for _, h := range headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func TestTypedWithFakeClient(t *testing.T) {
+	client := kafkatest.NewClient(kafka.KafkaConfig{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := kafka.PublishTyped(ctx, client, kafka.JSONCodec{}, "orders", []byte("o-1"), order{ID: "o-1", Total: 42}); err != nil {
+		t.Fatalf("PublishTyped failed: %v", err)
+	}
+	published := client.Published("orders")
+	if len(published) != 1 {
+		t.Fatalf("published %d messages, want 1", len(published))
+	}
+	if got := header(published[0].Headers, "content-type"); got != "application/json" {
+		t.Errorf("content-type = %q, want application/json", got)
+	}
+	client.Inject("orders", []byte("bad"), []byte("{not json"))
+
+	received := make(chan order, 2)
+	go kafka.SubscribeTyped(ctx, client, kafka.JSONCodec{}, []string{"orders"},
+		func(_ context.Context, o order, _ *sarama.ConsumerMessage) error {
+			received <- o
+			return nil
+		})
+
+	if err := client.WaitConsumed(ctx, "orders", 2); err != nil {
+		t.Fatalf("messages were not consumed: %v", err)
+	}
+	if got := <-received; got != (order{ID: "o-1", Total: 42}) {
+		t.Errorf("received %+v", got)
+	}
+
+	letters := client.DeadLetters()
+	if len(letters) != 1 {
+		t.Fatalf("dead-lettered %d messages, want 1", len(letters))
+	}
+	if header(letters[0].Headers, "decode-error") == "" {
+		t.Error("dead letter has no decode-error header")
+	}
+	if got := header(letters[0].Headers, "original-topic"); got != "orders" {
+		t.Errorf("original-topic = %q, want orders", got)
+	}
+}
@@ -0,0 +1,27 @@
+package rabbitmq
+
+import (
+	"context"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// API is the publishing, consuming and dead letter surface of Client. Depend
+// on it instead of *Client to swap in rabbitmqtest.Client in unit tests.
+type API interface {
+	PublishWithMiddleware(ctx context.Context, queue string, body interface{}, middlewares ...Middleware) error
+	PublishRaw(ctx context.Context, queue string, msg amqp091.Publishing) error
+	PublishRawOnce(ctx context.Context, queue string, msg amqp091.Publishing) error
+	PublishToExchange(ctx context.Context, exchange, routingKey string, body interface{}, opts PublishOptions) error
+	DeclareTopology(t Topology) error
+	ConsumeWithMiddleware(ctx context.Context, queue string, handler func(context.Context, interface{}) error, target interface{}, middlewares ...Middleware) (Consumer, error)
+	ConsumeWithOptions(ctx context.Context, queue string, opts ConsumerOptions, handler func(context.Context, interface{}) error, target interface{}, middlewares ...Middleware) (Consumer, error)
+	ConsumeDeliveries(ctx context.Context, queue string, handler func(context.Context, amqp091.Delivery)) (Consumer, error)
+	ConsumeDeliveriesWithOptions(ctx context.Context, queue string, opts ConsumerOptions, handler func(context.Context, amqp091.Delivery)) (Consumer, error)
+	HandleFailure(ctx context.Context, queue string, d amqp091.Delivery, cause error, retryable bool)
+	ListDeadLetters(ctx context.Context, filter DeadLetterFilter) ([]DeadLetter, error)
+	RedriveDeadLetters(ctx context.Context, filter DeadLetterFilter, ratePerSecond int) (int, error)
+	Close() error
+}
+
+var _ API = (*Client)(nil)
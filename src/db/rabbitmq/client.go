@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/rabbitmq/amqp091-go"
@@ -13,6 +14,19 @@ import (
 type Client struct {
 	conn   *RabbitConn
 	config *RabbitMQConfig
+
+	connMu      sync.RWMutex // Guards conn
+	reconnectMu sync.Mutex   // Serializes reconnects and Close
+	closed      chan struct{}
+
+	tagsMu sync.Mutex
+	tags   map[string]bool // Consumer tags of running consumers
+	tagSeq uint64
+
+	topologyMu sync.Mutex
+	topology   Topology
+	queues     map[string]amqp091.Table // Arguments of queues declared through the topology
+	passive    bool                     // Never declare topology (NewAdmin)
 }
 
 type Middleware func(context.Context, string, []byte) error
@@ -26,17 +40,44 @@ func NewClientWithConfig(cfg RabbitMQConfig) (*Client, error) {
 	client := &Client{
 		conn:   conn,
 		config: LoadRabbitMQConfig(cfg),
+		queues: make(map[string]amqp091.Table),
+		closed: make(chan struct{}),
+		tags:   make(map[string]bool),
+	}
+	// Copied so later declarations never write into the caller's slices
+	client.topology = Topology{}.merge(client.config.Topology)
+	for _, q := range client.topology.Queues {
+		client.queues[q.Name] = client.queueSpecArgs(q)
 	}
 
-	if err := client.setupDLX(); err != nil {
+	if err := client.setupTopology(conn.Channel); err != nil {
+		conn.Connection.Close()
 		return nil, err
 	}
 
 	return client, nil
 }
 
-func (c *Client) setupDLX() error {
-	err := c.conn.Channel.ExchangeDeclare(
+// NewAdmin connects without declaring the dead letter exchange or any
+// topology, for tools that only inspect and redrive existing queues
+func NewAdmin(cfg RabbitMQConfig) (*Client, error) {
+	conn, err := ConnectFromEnv(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		conn:    conn,
+		config:  LoadRabbitMQConfig(cfg),
+		queues:  make(map[string]amqp091.Table),
+		closed:  make(chan struct{}),
+		tags:    make(map[string]bool),
+		passive: true,
+	}, nil
+}
+
+func (c *Client) setupDLX(ch *amqp091.Channel) error {
+	err := ch.ExchangeDeclare(
 		c.config.DeadLetterExchange,
 		"direct",
 		true,
@@ -49,7 +90,7 @@ func (c *Client) setupDLX() error {
 		return err
 	}
 
-	_, err = c.conn.Channel.QueueDeclare(
+	_, err = ch.QueueDeclare(
 		c.config.DeadLetterQueue,
 		true,
 		false,
@@ -61,9 +102,10 @@ func (c *Client) setupDLX() error {
 		return err
 	}
 
-	return c.conn.Channel.QueueBind(
+	// Queues dead-letter with the DLQ name as routing key (see deadLetterArgs)
+	return ch.QueueBind(
+		c.config.DeadLetterQueue,
 		c.config.DeadLetterQueue,
-		"",
 		c.config.DeadLetterExchange,
 		false,
 		nil,
@@ -83,37 +125,57 @@ func (c *Client) PublishWithMiddleware(ctx context.Context, queue string, body i
 	}
 
 	return c.retryOperation(ctx, func() error {
-		return c.publish(queue, data)
+		return c.publish(ctx, queue, data)
 	})
 }
 
-func (c *Client) publish(queue string, body []byte) error {
-	_, err := c.conn.Channel.QueueDeclare(
-		queue,
-		true,
-		false,
-		false,
-		false,
-		amqp091.Table{
-			"x-dead-letter-exchange": c.config.DeadLetterExchange,
-		},
-	)
-	if err != nil {
+// PublishRaw publishes a prepared message to queue with retries, leaving the
+// body, headers and properties untouched
+func (c *Client) PublishRaw(ctx context.Context, queue string, msg amqp091.Publishing) error {
+	return c.retryOperation(ctx, func() error {
+		return c.publishMessage(ctx, queue, msg)
+	})
+}
+
+// PublishRawOnce is PublishRaw with a single attempt, for callers such as the
+// outbox relay that schedule their own retries
+func (c *Client) PublishRawOnce(ctx context.Context, queue string, msg amqp091.Publishing) error {
+	if err := ctx.Err(); err != nil {
 		return err
 	}
+	return c.publishMessage(ctx, queue, msg)
+}
 
-	return c.conn.Channel.PublishWithContext(
-		context.Background(),
-		"",
-		queue,
-		false,
-		false,
-		amqp091.Publishing{
-			ContentType: "application/json",
-			Body:        body,
-			Timestamp:   time.Now(),
-		},
-	)
+func (c *Client) publish(ctx context.Context, queue string, body []byte) error {
+	return c.publishMessage(ctx, queue, amqp091.Publishing{
+		ContentType: "application/json",
+		Body:        body,
+		Timestamp:   time.Now(),
+	})
+}
+
+// publishMessage declares queue and publishes msg to it, returning once the
+// broker has confirmed the message
+func (c *Client) publishMessage(ctx context.Context, queue string, msg amqp091.Publishing) error {
+	return c.withPublisher(ctx, func(pc *publishChannel) error {
+		_, err := pc.ch.QueueDeclare(
+			queue,
+			true,
+			false,
+			false,
+			false,
+			c.queueArgs(queue),
+		)
+		if err != nil {
+			return err
+		}
+
+		return publishConfirmed(ctx, pc.ch, pc.returns, c.confirmTimeout(), "", queue, msg)
+	})
+}
+
+func (c *Client) confirmTimeout() time.Duration {
+	return time.Duration(c.config.ConfirmTimeoutSeconds) * time.Second
 }
 
 func (c *Client) retryOperation(ctx context.Context, operation func() error) error {
@@ -134,16 +196,27 @@ func (c *Client) retryOperation(ctx context.Context, operation func() error) err
 }
 
 func (c *Client) Close() error {
+	c.reconnectMu.Lock()
+	defer c.reconnectMu.Unlock()
+
+	select {
+	case <-c.closed:
+		return nil
+	default:
+		close(c.closed)
+	}
+
 	var errs []error
 
-	if c.conn != nil && c.conn.Channel != nil {
-		if err := c.conn.Channel.Close(); err != nil {
+	conn := c.connection()
+	if conn != nil && conn.Channel != nil {
+		if err := conn.Channel.Close(); err != nil {
 			errs = append(errs, fmt.Errorf("failed to close channel: %w", err))
 		}
 	}
 
-	if c.conn != nil && c.conn.Connection != nil {
-		if err := c.conn.Connection.Close(); err != nil {
+	if conn != nil && conn.Connection != nil {
+		if err := conn.Connection.Close(); err != nil {
 			errs = append(errs, fmt.Errorf("failed to close connection: %w", err))
 		}
 	}
@@ -6,6 +6,16 @@ type RabbitMQConfig struct {
 	RetryDelaySeconds  int    // Delay between retries in seconds
 	DeadLetterExchange string // Dead letter exchange name
 	DeadLetterQueue    string // Dead letter queue name
+
+	ConfirmTimeoutSeconds int // How long a publish waits for the broker's confirmation
+	PublishChannels       int // Size of the publish channel pool
+	Prefetch              int // Default consumer prefetch count
+	ConsumerWorkers       int // Default number of concurrent handlers per consumer
+
+	MaxHandlerRetries int   // Delayed retries of a failing handler before dead-lettering
+	RetryBackoffMs    []int // Delay of each retry; the last tier repeats
+
+	Topology Topology // Exchanges, queues and bindings declared on connect and reconnect
 }
 
 func DefaultConfig() *RabbitMQConfig {
@@ -14,6 +24,14 @@ func DefaultConfig() *RabbitMQConfig {
 		RetryDelaySeconds:  5,
 		DeadLetterExchange: "dlx.exchange",
 		DeadLetterQueue:    "dlx.queue",
+
+		ConfirmTimeoutSeconds: 5,
+		PublishChannels:       4,
+		Prefetch:              1,
+		ConsumerWorkers:       1,
+
+		MaxHandlerRetries: 3,
+		RetryBackoffMs:    []int{1000, 5000, 30000},
 	}
 }
 
@@ -34,5 +52,24 @@ func LoadRabbitMQConfig(cfg RabbitMQConfig) *RabbitMQConfig {
 	if cfg.DeadLetterQueue != "" {
 		defaultCfg.DeadLetterQueue = cfg.DeadLetterQueue
 	}
+	defaultCfg.Topology = cfg.Topology
+	if cfg.ConfirmTimeoutSeconds > 0 {
+		defaultCfg.ConfirmTimeoutSeconds = cfg.ConfirmTimeoutSeconds
+	}
+	if cfg.PublishChannels > 0 {
+		defaultCfg.PublishChannels = cfg.PublishChannels
+	}
+	if cfg.Prefetch > 0 {
+		defaultCfg.Prefetch = cfg.Prefetch
+	}
+	if cfg.ConsumerWorkers > 0 {
+		defaultCfg.ConsumerWorkers = cfg.ConsumerWorkers
+	}
+	if cfg.MaxHandlerRetries > 0 {
+		defaultCfg.MaxHandlerRetries = cfg.MaxHandlerRetries
+	}
+	if len(cfg.RetryBackoffMs) > 0 {
+		defaultCfg.RetryBackoffMs = cfg.RetryBackoffMs
+	}
 	return defaultCfg
 }
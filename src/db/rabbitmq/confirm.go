@@ -0,0 +1,73 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+var (
+	ErrPublishNacked  = errors.New("publish was nacked by the broker")
+	ErrPublishTimeout = errors.New("timed out waiting for publish confirmation")
+	ErrUnroutable     = errors.New("message was returned as unroutable")
+)
+
+// confirmChannel puts ch in confirm mode and subscribes to messages the broker
+// returns because a mandatory publish could not be routed
+func confirmChannel(ch *amqp091.Channel) (<-chan amqp091.Return, error) {
+	if err := ch.Confirm(false); err != nil {
+		return nil, fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
+	return ch.NotifyReturn(make(chan amqp091.Return, 1)), nil
+}
+
+// publishConfirmed publishes msg as mandatory and persistent and waits up to
+// timeout for the broker to confirm it. Callers must not publish on ch
+// concurrently, since returns are matched to the publish that preceded them.
+func publishConfirmed(ctx context.Context, ch *amqp091.Channel, returns <-chan amqp091.Return,
+	timeout time.Duration, exchange, key string, msg amqp091.Publishing) error {
+
+	// Drop returns left over from a publish that timed out
+	for drained := false; !drained; {
+		select {
+		case <-returns:
+		default:
+			drained = true
+		}
+	}
+
+	if msg.DeliveryMode == 0 {
+		msg.DeliveryMode = amqp091.Persistent
+	}
+
+	confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, exchange, key, true, false, msg)
+	if err != nil {
+		return err
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	acked, err := confirm.WaitContext(waitCtx)
+	if err != nil {
+		if ctx.Err() == nil {
+			return fmt.Errorf("%w after %v", ErrPublishTimeout, timeout)
+		}
+		return err
+	}
+
+	// The broker sends basic.return before the confirmation of the same message
+	select {
+	case ret := <-returns:
+		return fmt.Errorf("%w: %d %s", ErrUnroutable, ret.ReplyCode, ret.ReplyText)
+	default:
+	}
+
+	if !acked {
+		return ErrPublishNacked
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package rabbitmq
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -8,14 +9,23 @@ import (
 	"github.com/rabbitmq/amqp091-go"
 )
 
+var ErrClientClosed = errors.New("rabbitmq client is closed")
+
+// RabbitConn is one AMQP connection with a channel for topology declarations
+// and a pool of confirm-mode channels for publishing. Consumers open their own
+// channels, since AMQP channels must not be shared between goroutines.
 type RabbitConn struct {
 	Connection *amqp091.Connection
 	Channel    *amqp091.Channel
+
+	publishers chan *publishChannel
 }
 
 func ConnectFromEnv(cfg RabbitMQConfig) (*RabbitConn, error) {
-	config := LoadRabbitMQConfig(cfg)
+	return dial(LoadRabbitMQConfig(cfg))
+}
 
+func dial(config *RabbitMQConfig) (*RabbitConn, error) {
 	conn, err := amqp091.DialConfig(config.URL, amqp091.Config{
 		Heartbeat: 10 * time.Second,
 	})
@@ -29,39 +39,106 @@ func ConnectFromEnv(cfg RabbitMQConfig) (*RabbitConn, error) {
 		return nil, fmt.Errorf("failed to open channel: %w", err)
 	}
 
-	if err := ch.Qos(1, 0, false); err != nil {
+	publishers, err := newPublisherPool(conn, config.PublishChannels)
+	if err != nil {
 		conn.Close()
-		return nil, fmt.Errorf("failed to set QoS: %w", err)
+		return nil, err
 	}
 
 	return &RabbitConn{
 		Connection: conn,
 		Channel:    ch,
+		publishers: publishers,
 	}, nil
 }
 
+// Reconnect dials a new connection and restores the topology on it. It does
+// not replace the client's connection; consumers and publishers do that
+// themselves when they find the connection closed.
 func (c *Client) Reconnect() (*RabbitConn, error) {
 	cfg := LoadRabbitMQConfig(*c.config)
 
-	var conn *amqp091.Connection
-	var ch *amqp091.Channel
 	var err error
-
 	for attempt := 1; attempt <= cfg.MaxRetries; attempt++ {
-		conn, err = amqp091.DialConfig(cfg.URL, amqp091.Config{Heartbeat: 10 * time.Second})
+		var conn *RabbitConn
+		conn, err = dial(cfg)
 		if err == nil {
-			ch, err = conn.Channel()
-			if err == nil {
-				if err := ch.Qos(1, 0, false); err != nil {
-					conn.Close()
-					return nil, err
-				}
-				return &RabbitConn{Connection: conn, Channel: ch}, nil
+			if err = c.setupTopology(conn.Channel); err == nil {
+				return conn, nil
 			}
-			conn.Close()
+			conn.Connection.Close()
+			err = fmt.Errorf("failed to restore topology: %w", err)
 		}
 		log.Printf("[RabbitMQ] Reconnect attempt %d/%d failed: %v. Retrying in %ds", attempt, cfg.MaxRetries, err, cfg.RetryDelaySeconds)
 		time.Sleep(time.Duration(cfg.RetryDelaySeconds) * time.Second)
 	}
 	return nil, fmt.Errorf("failed to reconnect after %d attempts: %w", cfg.MaxRetries, err)
 }
+
+// connection returns the current connection
+func (c *Client) connection() *RabbitConn {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+	return c.conn
+}
+
+// liveConnection returns the current connection, reconnecting first when it
+// has dropped
+func (c *Client) liveConnection() (*RabbitConn, error) {
+	conn := c.connection()
+	if conn.Connection.IsClosed() {
+		return c.reconnect(conn)
+	}
+	return conn, nil
+}
+
+// openChannel opens a fresh channel on a live connection, for work that
+// should not share the publisher pool
+func (c *Client) openChannel() (*amqp091.Channel, error) {
+	conn, err := c.liveConnection()
+	if err != nil {
+		return nil, err
+	}
+
+	ch, err := conn.Connection.Channel()
+	if errors.Is(err, amqp091.ErrClosed) {
+		// Dropped since the check above
+		if conn, err = c.reconnect(conn); err != nil {
+			return nil, err
+		}
+		ch, err = conn.Connection.Channel()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open channel: %w", err)
+	}
+	return ch, nil
+}
+
+// reconnect replaces broken with a new connection, unless another goroutine
+// already did so, and returns the connection to use
+func (c *Client) reconnect(broken *RabbitConn) (*RabbitConn, error) {
+	c.reconnectMu.Lock()
+	defer c.reconnectMu.Unlock()
+
+	select {
+	case <-c.closed:
+		return nil, ErrClientClosed
+	default:
+	}
+	if current := c.connection(); current != broken {
+		return current, nil
+	}
+
+	conn, err := c.Reconnect()
+	if err != nil {
+		return nil, err
+	}
+
+	c.connMu.Lock()
+	c.conn = conn
+	c.connMu.Unlock()
+
+	broken.Connection.Close()
+	log.Printf("[RabbitMQ] Reconnected")
+	return conn, nil
+}
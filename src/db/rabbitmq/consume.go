@@ -3,118 +3,301 @@ package rabbitmq
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rabbitmq/amqp091-go"
 )
 
+// ConsumerOptions tunes a single consumer; zero fields fall back to the client config
+type ConsumerOptions struct {
+	Prefetch    int    // Unacked deliveries the broker sends ahead; at least Workers
+	Workers     int    // Deliveries handled concurrently, each acked on its own
+	ConsumerTag string // Must be unique per client; generated when empty
+}
+
+// ConsumeWithMiddleware unmarshals each message from queue into a new value of
+// the type target points to and passes it to handler. It returns once the
+// consumer is started; use the returned Consumer to stop it or learn why it ended.
 func (c *Client) ConsumeWithMiddleware(ctx context.Context, queue string,
 	handler func(context.Context, interface{}) error, target interface{},
-	middlewares ...Middleware) error {
+	middlewares ...Middleware) (Consumer, error) {
 
-	go func() {
-		backoff := time.Duration(c.config.RetryDelaySeconds) * time.Second
-		maxBackoff := 60 * time.Second
+	return c.ConsumeWithOptions(ctx, queue, ConsumerOptions{}, handler, target, middlewares...)
+}
 
-		for {
-			select {
-			case <-ctx.Done():
+// ConsumeWithOptions is ConsumeWithMiddleware with per-consumer prefetch,
+// worker count and consumer tag
+func (c *Client) ConsumeWithOptions(ctx context.Context, queue string, opts ConsumerOptions,
+	handler func(context.Context, interface{}) error, target interface{},
+	middlewares ...Middleware) (Consumer, error) {
+
+	targetType := reflect.TypeOf(target)
+	if targetType == nil || targetType.Kind() != reflect.Ptr {
+		return nil, fmt.Errorf("target must be a non-nil pointer, got %T", target)
+	}
+
+	return c.startConsumer(ctx, queue, opts, func(ctx context.Context, msg amqp091.Delivery) {
+		data := reflect.New(targetType.Elem()).Interface()
+		if err := json.Unmarshal(msg.Body, data); err != nil {
+			log.Printf("[Worker] JSON unmarshal failed: %v", err)
+			c.handleFailure(ctx, queue, msg, fmt.Errorf("unmarshal failed: %w", err), false)
+			return
+		}
+
+		// Middleware failures are retried and dead-lettered like handler failures
+		for _, mw := range middlewares {
+			if err := mw(ctx, queue, msg.Body); err != nil {
+				log.Printf("[Worker] Middleware failed: %v", err)
+				c.handleFailure(ctx, queue, msg, fmt.Errorf("middleware failed: %w", err), true)
 				return
-			default:
 			}
+		}
 
-			// Reconnect if connection closed
-			if c.conn.Connection.IsClosed() {
-				log.Printf("[Worker] RabbitMQ connection closed, reconnecting...")
-				newConn, err := c.Reconnect()
-				if err != nil {
-					log.Printf("[Worker] Failed to reconnect: %v. Retrying in %v", err, backoff)
-					time.Sleep(backoff)
-					if backoff < maxBackoff {
-						backoff *= 2
-					}
-					continue
-				}
-				c.conn = newConn
-				backoff = time.Duration(c.config.RetryDelaySeconds) * time.Second
-			}
+		// Failures are retried through the delayed retry queues
+		if err := handler(ctx, data); err != nil {
+			c.handleFailure(ctx, queue, msg, err, true)
+			return
+		}
 
-			// Declare queue
-			_, err := c.conn.Channel.QueueDeclare(
-				queue,
-				true,
-				false,
-				false,
-				false,
-				amqp091.Table{"x-dead-letter-exchange": c.config.DeadLetterExchange},
-			)
-			if err != nil {
-				log.Printf("[Worker] Failed to declare queue %s: %v. Reconnecting...", queue, err)
-				c.conn.Connection.Close()
-				continue
-			}
+		if err := msg.Ack(false); err != nil {
+			log.Printf("[Worker] Failed to ack message: %v", err)
+		} else {
+			log.Printf("[Worker] Message processed and acked")
+		}
+	})
+}
 
-			// Start consuming
-			msgs, err := c.conn.Channel.Consume(queue, "", false, false, false, false, nil)
-			if err != nil {
-				log.Printf("[Worker] Failed to consume queue %s: %v. Reconnecting...", queue, err)
-				c.conn.Connection.Close()
-				continue
-			}
+// ConsumeDeliveries hands raw deliveries from queue to handler, which must
+// Ack or Nack every delivery itself. Reconnects like ConsumeWithMiddleware.
+func (c *Client) ConsumeDeliveries(ctx context.Context, queue string, handler func(context.Context, amqp091.Delivery)) (Consumer, error) {
+	return c.ConsumeDeliveriesWithOptions(ctx, queue, ConsumerOptions{}, handler)
+}
 
-			log.Printf("[Worker] Started consumer for queue: %s", queue)
+// ConsumeDeliveriesWithOptions is ConsumeDeliveries with per-consumer
+// prefetch, worker count and consumer tag
+func (c *Client) ConsumeDeliveriesWithOptions(ctx context.Context, queue string, opts ConsumerOptions, handler func(context.Context, amqp091.Delivery)) (Consumer, error) {
+	return c.startConsumer(ctx, queue, opts, handler)
+}
 
-			for msg := range msgs {
-				data := reflect.New(reflect.TypeOf(target).Elem()).Interface()
-				if err := json.Unmarshal(msg.Body, data); err != nil {
-					log.Printf("[Worker] JSON unmarshal failed: %v", err)
-					msg.Nack(false, false)
-					continue
-				}
+// startConsumer reserves the consumer tag and runs consumeLoop in the background
+func (c *Client) startConsumer(ctx context.Context, queue string, opts ConsumerOptions, handle func(context.Context, amqp091.Delivery)) (Consumer, error) {
+	opts = c.consumerOptions(queue, opts)
+	if err := c.reserveTag(opts.ConsumerTag); err != nil {
+		return nil, err
+	}
 
-				// Middleware
-				for _, mw := range middlewares {
-					if err := mw(ctx, queue, msg.Body); err != nil {
-						log.Printf("[Worker] Middleware failed: %v", err)
-						msg.Nack(false, true)
-						continue
-					}
-				}
+	ctx, cancel := context.WithCancel(ctx)
+	cons := newConsumer(opts.ConsumerTag, cancel)
 
-				// Handler retry
-				const maxHandlerRetries = 3
-				var handlerErr error
-				for attempt := 1; attempt <= maxHandlerRetries; attempt++ {
-					handlerErr = handler(ctx, data)
-					if handlerErr == nil {
-						break
-					}
-					log.Printf("[Worker] Handler failed (attempt %d/%d): %v", attempt, maxHandlerRetries, handlerErr)
-					time.Sleep(time.Second * time.Duration(attempt))
-				}
+	go func() {
+		defer c.releaseTag(opts.ConsumerTag)
+		defer cancel()
 
-				if handlerErr != nil {
-					msg.Nack(false, false)
-					continue
-				}
+		err := c.consumeLoop(ctx, queue, opts, cons, handle)
+		if err != nil {
+			log.Printf("[Worker] Consumer %s for queue %s stopped: %v", opts.ConsumerTag, queue, err)
+		}
+		cons.finish(err)
+	}()
+	return cons, nil
+}
+
+func (c *Client) consumerOptions(queue string, opts ConsumerOptions) ConsumerOptions {
+	if opts.Workers <= 0 {
+		opts.Workers = c.config.ConsumerWorkers
+	}
+	if opts.Prefetch <= 0 {
+		opts.Prefetch = c.config.Prefetch
+	}
+	if opts.Prefetch < opts.Workers {
+		opts.Prefetch = opts.Workers
+	}
+	if opts.ConsumerTag == "" {
+		opts.ConsumerTag = fmt.Sprintf("%s-%d", queue, atomic.AddUint64(&c.tagSeq, 1))
+	}
+	return opts
+}
+
+func (c *Client) reserveTag(tag string) error {
+	c.tagsMu.Lock()
+	defer c.tagsMu.Unlock()
+
+	if c.tags[tag] {
+		return fmt.Errorf("consumer tag %s is already in use", tag)
+	}
+	c.tags[tag] = true
+	return nil
+}
 
-				if err := msg.Ack(false); err != nil {
-					log.Printf("[Worker] Failed to ack message: %v", err)
-				} else {
-					log.Printf("[Worker] Message processed and acked")
+func (c *Client) releaseTag(tag string) {
+	c.tagsMu.Lock()
+	defer c.tagsMu.Unlock()
+	delete(c.tags, tag)
+}
+
+// consumeLoop declares queue and consumes it on a dedicated channel,
+// reopening the channel and reconnecting with backoff until ctx is cancelled,
+// the consumer is stopped or a fatal error occurs
+func (c *Client) consumeLoop(ctx context.Context, queue string, opts ConsumerOptions, cons *consumer, handle func(context.Context, amqp091.Delivery)) error {
+	backoff := time.Duration(c.config.RetryDelaySeconds) * time.Second
+	maxBackoff := 60 * time.Second
+
+	wait := func() bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-c.closed:
+			return false
+		case <-cons.stop:
+			return false
+		case <-time.After(backoff):
+		}
+		if backoff < maxBackoff {
+			backoff *= 2
+		}
+		return true
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-cons.stop:
+			return nil
+		case <-c.closed:
+			return ErrClientClosed
+		default:
+		}
+
+		// Reconnect if connection closed
+		conn := c.connection()
+		if conn.Connection.IsClosed() {
+			log.Printf("[Worker] RabbitMQ connection closed, reconnecting...")
+			if _, err := c.reconnect(conn); err != nil {
+				if errors.Is(err, ErrClientClosed) {
+					return err
 				}
+				log.Printf("[Worker] Failed to reconnect: %v. Retrying in %v", err, backoff)
+				wait()
+				continue
 			}
+			backoff = time.Duration(c.config.RetryDelaySeconds) * time.Second
+			continue
+		}
+
+		ch, err := conn.Connection.Channel()
+		if err != nil {
+			log.Printf("[Worker] Failed to open channel for queue %s: %v. Retrying in %v", queue, err, backoff)
+			wait()
+			continue
+		}
 
-			log.Printf("[Worker] Channel closed for queue %s, reconnecting in %v...", queue, backoff)
-			c.conn.Connection.Close()
-			time.Sleep(backoff)
-			if backoff < maxBackoff {
-				backoff *= 2
+		msgs, err := c.subscribe(ch, queue, opts)
+		if err != nil {
+			ch.Close()
+			if isFatal(err) {
+				return err
 			}
+			log.Printf("[Worker] %v. Retrying in %v", err, backoff)
+			wait()
+			continue
+		}
+
+		if !cons.attach(ch) {
+			ch.Cancel(opts.ConsumerTag, false)
+		}
+		log.Printf("[Worker] Started consumer %s for queue: %s (%d workers, prefetch %d)", opts.ConsumerTag, queue, opts.Workers, opts.Prefetch)
+		backoff = time.Duration(c.config.RetryDelaySeconds) * time.Second
+
+		dispatch(ctx, msgs, opts.Workers, handle)
+		cons.detach()
+
+		// Unacked deliveries still buffered on the channel are requeued on close
+		ch.Close()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-cons.stop:
+			return nil
+		default:
 		}
+
+		log.Printf("[Worker] Channel closed for queue %s, resubscribing in %v...", queue, backoff)
+		wait()
+	}
+}
+
+// subscribe sets the prefetch, declares queue and starts consuming it on ch
+func (c *Client) subscribe(ch *amqp091.Channel, queue string, opts ConsumerOptions) (<-chan amqp091.Delivery, error) {
+	if err := ch.Qos(opts.Prefetch, 0, false); err != nil {
+		return nil, fmt.Errorf("failed to set QoS for queue %s: %w", queue, err)
+	}
+
+	// Declare queue
+	_, err := ch.QueueDeclare(
+		queue,
+		true,
+		false,
+		false,
+		false,
+		c.queueArgs(queue),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to declare queue %s: %w", queue, err)
+	}
+
+	msgs, err := ch.Consume(queue, opts.ConsumerTag, false, false, false, false, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume queue %s: %w", queue, err)
+	}
+	return msgs, nil
+}
+
+// dispatch hands deliveries to workers goroutines until msgs is closed or ctx
+// is cancelled, then waits for the deliveries in flight
+func dispatch(ctx context.Context, msgs <-chan amqp091.Delivery, workers int, handle func(context.Context, amqp091.Delivery)) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	deliveries := make(chan amqp091.Delivery)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for msg := range deliveries {
+				handle(ctx, msg)
+			}
+		}()
+	}
+
+	defer func() {
+		close(deliveries)
+		wg.Wait()
 	}()
 
-	return nil
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-msgs:
+			if !ok {
+				return
+			}
+			select {
+			case deliveries <- msg:
+			case <-ctx.Done():
+				msg.Nack(false, true)
+				return
+			}
+		}
+	}
 }
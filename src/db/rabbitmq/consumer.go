@@ -0,0 +1,120 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// Consumer is a running consumer started by ConsumeWithMiddleware or ConsumeDeliveries
+type Consumer interface {
+	Tag() string
+	// Stop cancels the consumer tag so the broker stops delivering, waits for
+	// in-flight messages to finish and returns early with ctx.Err() if ctx
+	// ends first, in which case handlers see their context cancelled
+	Stop(ctx context.Context) error
+	// Done is closed once the consumer has stopped for any reason
+	Done() <-chan struct{}
+	// Err is the fatal error that stopped the consumer, or nil after Stop,
+	// context cancellation or before Done is closed
+	Err() error
+}
+
+type consumer struct {
+	tag    string
+	cancel context.CancelFunc
+	done   chan struct{}
+	stop   chan struct{}
+
+	mu      sync.Mutex
+	ch      *amqp091.Channel
+	stopped bool
+	err     error
+}
+
+func newConsumer(tag string, cancel context.CancelFunc) *consumer {
+	return &consumer{
+		tag:    tag,
+		cancel: cancel,
+		done:   make(chan struct{}),
+		stop:   make(chan struct{}),
+	}
+}
+
+func (c *consumer) Tag() string {
+	return c.tag
+}
+
+func (c *consumer) Stop(ctx context.Context) error {
+	c.mu.Lock()
+	if !c.stopped {
+		c.stopped = true
+		close(c.stop)
+		if c.ch != nil {
+			// Closes the delivery channel once the broker confirms the cancel
+			c.ch.Cancel(c.tag, false)
+		}
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		c.cancel()
+		return ctx.Err()
+	}
+}
+
+func (c *consumer) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *consumer) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// attach records the channel deliveries arrive on and reports false when the
+// consumer was stopped meanwhile, in which case the caller cancels it
+func (c *consumer) attach(ch *amqp091.Channel) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stopped {
+		return false
+	}
+	c.ch = ch
+	return true
+}
+
+func (c *consumer) detach() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ch = nil
+}
+
+func (c *consumer) finish(err error) {
+	c.mu.Lock()
+	c.err = err
+	c.ch = nil
+	c.mu.Unlock()
+	close(c.done)
+}
+
+// isFatal reports broker errors that a reconnect cannot fix, such as a queue
+// declared with different arguments or missing permissions
+func isFatal(err error) bool {
+	var amqpErr *amqp091.Error
+	if !errors.As(err, &amqpErr) {
+		return false
+	}
+	switch amqpErr.Code {
+	case amqp091.AccessRefused, amqp091.NotFound, amqp091.PreconditionFailed:
+		return true
+	}
+	return false
+}
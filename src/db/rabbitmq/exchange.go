@@ -0,0 +1,72 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// PublishOptions sets the AMQP properties of a message published to an exchange
+type PublishOptions struct {
+	Headers       amqp091.Table
+	Priority      uint8         // Needs x-max-priority on the target queues
+	Expiration    time.Duration // Per-message TTL; zero never expires
+	CorrelationID string
+	ReplyTo       string
+	MessageID     string
+	ContentType   string // Defaults to application/json, or application/octet-stream for []byte bodies
+	Type          string
+}
+
+// PublishToExchange publishes body to exchange with routingKey and waits for
+// the broker's confirmation, retrying like PublishWithMiddleware. A []byte
+// body is sent as is; anything else is marshaled as JSON.
+func (c *Client) PublishToExchange(ctx context.Context, exchange, routingKey string, body interface{}, opts PublishOptions) error {
+	msg, err := newPublishing(body, opts)
+	if err != nil {
+		return err
+	}
+
+	return c.retryOperation(ctx, func() error {
+		return c.withPublisher(ctx, func(pc *publishChannel) error {
+			return publishConfirmed(ctx, pc.ch, pc.returns, c.confirmTimeout(), exchange, routingKey, msg)
+		})
+	})
+}
+
+func newPublishing(body interface{}, opts PublishOptions) (amqp091.Publishing, error) {
+	msg := amqp091.Publishing{
+		Headers:       opts.Headers,
+		ContentType:   opts.ContentType,
+		Priority:      opts.Priority,
+		CorrelationId: opts.CorrelationID,
+		ReplyTo:       opts.ReplyTo,
+		MessageId:     opts.MessageID,
+		Type:          opts.Type,
+		Timestamp:     time.Now(),
+	}
+	if opts.Expiration > 0 {
+		msg.Expiration = strconv.FormatInt(opts.Expiration.Milliseconds(), 10)
+	}
+
+	if raw, ok := body.([]byte); ok {
+		msg.Body = raw
+		if msg.ContentType == "" {
+			msg.ContentType = "application/octet-stream"
+		}
+		return msg, nil
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return msg, err
+	}
+	msg.Body = data
+	if msg.ContentType == "" {
+		msg.ContentType = "application/json"
+	}
+	return msg, nil
+}
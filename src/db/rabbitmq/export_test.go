@@ -0,0 +1,14 @@
+package rabbitmq
+
+import "time"
+
+// NewTestClient returns an unconnected Client for comparing its retry policy
+// with rabbitmqtest.Client
+func NewTestClient(cfg RabbitMQConfig) *Client {
+	return &Client{config: LoadRabbitMQConfig(cfg)}
+}
+
+// RetryDelay exposes the backoff tier used for the given retry
+func (c *Client) RetryDelay(retry int) time.Duration {
+	return c.retryDelay(retry)
+}
@@ -0,0 +1,108 @@
+package rabbitmq_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+
+	"github.com/Ajinx1/go-storage-config/src/db/rabbitmq"
+	"github.com/Ajinx1/go-storage-config/src/db/rabbitmq/rabbitmqtest"
+)
+
+func headerKeys(headers amqp091.Table) []string {
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func equalKeys(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+type order struct {
+	ID string `json:"id"`
+}
+
+func TestFakeRetriesMatchClient(t *testing.T) {
+	cfg := rabbitmq.RabbitMQConfig{MaxHandlerRetries: 3, RetryBackoffMs: []int{100, 2000}}
+	real := rabbitmq.NewTestClient(cfg)
+	fake := rabbitmqtest.NewClient(cfg)
+	errHandler := errors.New("handler failed")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	original := amqp091.Table{"trace-id": "t-1"}
+	fake.Inject("orders", amqp091.Publishing{Headers: original, MessageId: "m-1", Body: []byte(`{"id":"o-1"}`)})
+
+	var attempts int
+	consumer, err := fake.ConsumeWithMiddleware(ctx, "orders", func(context.Context, interface{}) error {
+		attempts++
+		return errHandler
+	}, &order{})
+	if err != nil {
+		t.Fatalf("consume failed: %v", err)
+	}
+	if err := fake.WaitConsumed(ctx, "orders", cfg.MaxHandlerRetries+1); err != nil {
+		t.Fatalf("messages were not consumed: %v", err)
+	}
+	consumer.Stop(ctx)
+
+	if attempts != cfg.MaxHandlerRetries+1 {
+		t.Errorf("handler ran %d times, want %d", attempts, cfg.MaxHandlerRetries+1)
+	}
+
+	retries := fake.Retries()
+	if len(retries) != cfg.MaxHandlerRetries {
+		t.Fatalf("scheduled %d retries, want %d", len(retries), cfg.MaxHandlerRetries)
+	}
+	wantKeys := headerKeys(rabbitmq.FailureHeaders(original, "orders", 1, errHandler))
+	for i, r := range retries {
+		if want := rabbitmq.RetryQueueName("orders", real.RetryDelay(i)); r.Queue != want {
+			t.Errorf("retry %d went to %s, want %s", i+1, r.Queue, want)
+		}
+		if got := rabbitmq.RetryCount(r.Publishing.Headers); got != i+1 {
+			t.Errorf("retry %d has x-retry-count %d", i+1, got)
+		}
+		if got := headerKeys(r.Publishing.Headers); !equalKeys(got, wantKeys) {
+			t.Errorf("retry %d headers %v, want %v", i+1, got, wantKeys)
+		}
+	}
+
+	dead := fake.DeadLetters()
+	if len(dead) != 1 {
+		t.Fatalf("dead-lettered %d messages, want 1", len(dead))
+	}
+	if got := headerKeys(dead[0].Publishing.Headers); !equalKeys(got, wantKeys) {
+		t.Errorf("dead letter headers %v, want %v", got, wantKeys)
+	}
+	if got := rabbitmq.RetryCount(dead[0].Publishing.Headers); got != cfg.MaxHandlerRetries {
+		t.Errorf("dead letter has x-retry-count %d, want %d", got, cfg.MaxHandlerRetries)
+	}
+
+	letters, err := fake.ListDeadLetters(ctx, rabbitmq.DeadLetterFilter{})
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(letters) != 1 {
+		t.Fatalf("listed %d dead letters, want 1", len(letters))
+	}
+	if dl := letters[0]; dl.ID != "m-1" || dl.OriginalQueue != "orders" || dl.Reason != "rejected" || dl.Error != errHandler.Error() {
+		t.Errorf("dead letter parsed as %+v", dl)
+	}
+}
@@ -0,0 +1,66 @@
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// publishChannel is a confirm-mode channel used by one publisher at a time
+type publishChannel struct {
+	ch      *amqp091.Channel
+	returns <-chan amqp091.Return
+}
+
+func openPublishChannel(conn *amqp091.Connection) (*publishChannel, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open publish channel: %w", err)
+	}
+
+	returns, err := confirmChannel(ch)
+	if err != nil {
+		ch.Close()
+		return nil, err
+	}
+	return &publishChannel{ch: ch, returns: returns}, nil
+}
+
+func newPublisherPool(conn *amqp091.Connection, size int) (chan *publishChannel, error) {
+	pool := make(chan *publishChannel, size)
+	for i := 0; i < size; i++ {
+		pc, err := openPublishChannel(conn)
+		if err != nil {
+			return nil, err
+		}
+		pool <- pc
+	}
+	return pool, nil
+}
+
+// withPublisher runs fn with exclusive use of a publish channel, reconnecting
+// when the connection is closed and reopening channels the broker closed
+func (c *Client) withPublisher(ctx context.Context, fn func(pc *publishChannel) error) error {
+	conn, err := c.liveConnection()
+	if err != nil {
+		return err
+	}
+
+	var pc *publishChannel
+	select {
+	case pc = <-conn.publishers:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { conn.publishers <- pc }()
+
+	if pc.ch.IsClosed() {
+		fresh, err := openPublishChannel(conn.Connection)
+		if err != nil {
+			return err
+		}
+		pc = fresh
+	}
+	return fn(pc)
+}
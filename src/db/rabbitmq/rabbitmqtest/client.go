@@ -0,0 +1,488 @@
+// Package rabbitmqtest provides an in-memory stand-in for rabbitmq.Client so
+// handler, retry and dead-letter logic can be unit tested without a broker.
+package rabbitmqtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/Ajinx1/go-storage-config/src/db/rabbitmq"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+var ErrClosed = errors.New("rabbitmqtest: client is closed")
+
+// Publication is a message published to a fake queue
+type Publication struct {
+	Queue      string
+	Exchange   string // Empty for the default exchange
+	RoutingKey string
+	Publishing amqp091.Publishing
+}
+
+type delivery struct {
+	queue string
+	msg   amqp091.Publishing
+	count int // Times the message has been delivered
+}
+
+// Client mimics rabbitmq.Client: publishes are retried MaxRetries times,
+// queues are shared by competing consumers, requeued messages go to the back
+// of their queue and rejected messages are dead-lettered with an x-death
+// header through their queue's dead letter exchange and routing key, as the
+// broker would: to DeadLetterQueue by default, and dropped when the exchange
+// has no matching binding. ConsumeWithMiddleware failures
+// are retried through the retry queues and then dead-lettered with the same
+// headers as the real client, but retry delays are skipped.
+type Client struct {
+	config *rabbitmq.RabbitMQConfig
+
+	mu        sync.Mutex
+	queues    map[string][]*delivery
+	unacked   map[uint64]*delivery
+	nextTag   uint64
+	settled   map[string]int
+	published []Publication
+	retries   []Publication
+	dead      []Publication
+	failures  map[string][]error
+	exchanges map[string]rabbitmq.ExchangeSpec
+	tags      map[string]bool
+	tagSeq    int
+	bindings  []rabbitmq.BindingSpec
+	queueArgs map[string]amqp091.Table // Arguments of queues declared through the topology
+	changed   chan struct{}
+	closed    chan struct{}
+	isClosed  bool
+}
+
+var _ rabbitmq.API = (*Client)(nil)
+
+func NewClient(cfg rabbitmq.RabbitMQConfig) *Client {
+	config := rabbitmq.LoadRabbitMQConfig(cfg)
+	return &Client{
+		config:   config,
+		queues:   make(map[string][]*delivery),
+		unacked:  make(map[uint64]*delivery),
+		settled:  make(map[string]int),
+		failures: make(map[string][]error),
+		// The dead letter exchange and its binding, as declared by the real client
+		exchanges: map[string]rabbitmq.ExchangeSpec{
+			config.DeadLetterExchange: {Name: config.DeadLetterExchange, Kind: amqp091.ExchangeDirect},
+		},
+		bindings: []rabbitmq.BindingSpec{
+			{Queue: config.DeadLetterQueue, Exchange: config.DeadLetterExchange, RoutingKey: config.DeadLetterQueue},
+		},
+		queueArgs: make(map[string]amqp091.Table),
+		tags:      make(map[string]bool),
+		changed:   make(chan struct{}),
+		closed:    make(chan struct{}),
+	}
+}
+
+// Published returns the messages published to queue through the client, or all when queue is empty
+func (c *Client) Published(queue string) []Publication {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var out []Publication
+	for _, p := range c.published {
+		if queue == "" || p.Queue == queue {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// DeadLetters returns the messages rejected to the dead letter queue
+func (c *Client) DeadLetters() []Publication {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Publication(nil), c.dead...)
+}
+
+// Retries returns the messages scheduled for a delayed retry, addressed to their retry queue
+func (c *Client) Retries() []Publication {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Publication(nil), c.retries...)
+}
+
+// Inject enqueues a message on queue as if another publisher had sent it
+func (c *Client) Inject(queue string, msg amqp091.Publishing) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.enqueueLocked(&delivery{queue: queue, msg: msg})
+}
+
+// InjectJSON enqueues body marshaled as JSON on queue
+func (c *Client) InjectJSON(queue string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	c.Inject(queue, amqp091.Publishing{ContentType: "application/json", Body: data, Timestamp: time.Now()})
+	return nil
+}
+
+// FailPublish makes the next times publish attempts to a queue, or to an
+// exchange for PublishToExchange, fail with err
+func (c *Client) FailPublish(target string, err error, times int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := 0; i < times; i++ {
+		c.failures[target] = append(c.failures[target], err)
+	}
+}
+
+// WaitConsumed blocks until n deliveries from queue have been acked or
+// rejected; each delayed retry counts as a separate delivery
+func (c *Client) WaitConsumed(ctx context.Context, queue string, n int) error {
+	for {
+		c.mu.Lock()
+		done := c.settled[queue] >= n
+		changed := c.changed
+		c.mu.Unlock()
+		if done {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+func (c *Client) PublishWithMiddleware(ctx context.Context, queue string, body interface{}, middlewares ...rabbitmq.Middleware) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	for _, mw := range middlewares {
+		if err := mw(ctx, queue, data); err != nil {
+			return err
+		}
+	}
+
+	return c.PublishRaw(ctx, queue, amqp091.Publishing{
+		ContentType: "application/json",
+		Body:        data,
+		Timestamp:   time.Now(),
+	})
+}
+
+func (c *Client) PublishRaw(ctx context.Context, queue string, msg amqp091.Publishing) error {
+	return c.publishRaw(ctx, queue, msg, c.config.MaxRetries)
+}
+
+func (c *Client) PublishRawOnce(ctx context.Context, queue string, msg amqp091.Publishing) error {
+	return c.publishRaw(ctx, queue, msg, 1)
+}
+
+func (c *Client) publishRaw(ctx context.Context, queue string, msg amqp091.Publishing, attempts int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.isClosed {
+		return ErrClosed
+	}
+
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if lastErr = c.takeFailureLocked(queue); lastErr == nil {
+			c.published = append(c.published, Publication{Queue: queue, RoutingKey: queue, Publishing: msg})
+			c.enqueueLocked(&delivery{queue: queue, msg: msg})
+			return nil
+		}
+	}
+	return lastErr
+}
+
+func (c *Client) ConsumeWithMiddleware(ctx context.Context, queue string,
+	handler func(context.Context, interface{}) error, target interface{},
+	middlewares ...rabbitmq.Middleware) (rabbitmq.Consumer, error) {
+
+	return c.ConsumeWithOptions(ctx, queue, rabbitmq.ConsumerOptions{}, handler, target, middlewares...)
+}
+
+func (c *Client) ConsumeWithOptions(ctx context.Context, queue string, opts rabbitmq.ConsumerOptions,
+	handler func(context.Context, interface{}) error, target interface{},
+	middlewares ...rabbitmq.Middleware) (rabbitmq.Consumer, error) {
+
+	t := reflect.TypeOf(target)
+	if t == nil || t.Kind() != reflect.Ptr {
+		return nil, fmt.Errorf("target must be a non-nil pointer, got %T", target)
+	}
+
+	return c.ConsumeDeliveriesWithOptions(ctx, queue, opts, func(ctx context.Context, msg amqp091.Delivery) {
+		data := reflect.New(t.Elem()).Interface()
+		if err := json.Unmarshal(msg.Body, data); err != nil {
+			c.handleFailure(queue, msg, fmt.Errorf("unmarshal failed: %w", err), false)
+			return
+		}
+
+		for _, mw := range middlewares {
+			if err := mw(ctx, queue, msg.Body); err != nil {
+				c.handleFailure(queue, msg, fmt.Errorf("middleware failed: %w", err), true)
+				return
+			}
+		}
+
+		if err := handler(ctx, data); err != nil {
+			c.handleFailure(queue, msg, err, true)
+			return
+		}
+		msg.Ack(false)
+	})
+}
+
+func (c *Client) ConsumeDeliveries(ctx context.Context, queue string, handler func(context.Context, amqp091.Delivery)) (rabbitmq.Consumer, error) {
+	return c.ConsumeDeliveriesWithOptions(ctx, queue, rabbitmq.ConsumerOptions{}, handler)
+}
+
+// ConsumeDeliveriesWithOptions runs opts.Workers competing handlers; Prefetch is ignored
+func (c *Client) ConsumeDeliveriesWithOptions(ctx context.Context, queue string, opts rabbitmq.ConsumerOptions, handler func(context.Context, amqp091.Delivery)) (rabbitmq.Consumer, error) {
+	c.mu.Lock()
+	if opts.ConsumerTag == "" {
+		c.tagSeq++
+		opts.ConsumerTag = fmt.Sprintf("%s-%d", queue, c.tagSeq)
+	}
+	if c.tags[opts.ConsumerTag] {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("consumer tag %s is already in use", opts.ConsumerTag)
+	}
+	c.tags[opts.ConsumerTag] = true
+	c.mu.Unlock()
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = c.config.ConsumerWorkers
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cons := &consumer{
+		tag:    opts.ConsumerTag,
+		cancel: cancel,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				msg, ok := c.next(ctx, cons.stop, queue)
+				if !ok {
+					return
+				}
+				handler(ctx, msg)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		cancel()
+
+		c.mu.Lock()
+		delete(c.tags, opts.ConsumerTag)
+		closed := c.isClosed
+		c.mu.Unlock()
+
+		if closed {
+			cons.err = ErrClosed
+		}
+		close(cons.done)
+	}()
+	return cons, nil
+}
+
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.isClosed {
+		c.isClosed = true
+		close(c.closed)
+	}
+	return nil
+}
+
+// Ack implements amqp091.Acknowledger
+func (c *Client) Ack(tag uint64, _ bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	d, err := c.takeUnackedLocked(tag)
+	if err != nil {
+		return err
+	}
+	c.settleLocked(d.queue)
+	return nil
+}
+
+// Nack implements amqp091.Acknowledger
+func (c *Client) Nack(tag uint64, _ bool, requeue bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	d, err := c.takeUnackedLocked(tag)
+	if err != nil {
+		return err
+	}
+	if requeue {
+		c.enqueueLocked(d)
+		return nil
+	}
+	c.deadLetterLocked(d)
+	c.settleLocked(d.queue)
+	return nil
+}
+
+// Reject implements amqp091.Acknowledger
+func (c *Client) Reject(tag uint64, requeue bool) error {
+	return c.Nack(tag, false, requeue)
+}
+
+// next waits for the next ready message on queue
+func (c *Client) next(ctx context.Context, stop <-chan struct{}, queue string) (amqp091.Delivery, bool) {
+	for {
+		c.mu.Lock()
+		if c.isClosed {
+			c.mu.Unlock()
+			return amqp091.Delivery{}, false
+		}
+		if ready := c.queues[queue]; len(ready) > 0 {
+			d := ready[0]
+			c.queues[queue] = ready[1:]
+			d.count++
+			c.nextTag++
+			c.unacked[c.nextTag] = d
+			msg := toDelivery(c, c.nextTag, d)
+			c.mu.Unlock()
+			return msg, true
+		}
+		changed := c.changed
+		c.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return amqp091.Delivery{}, false
+		case <-stop:
+			return amqp091.Delivery{}, false
+		case <-c.closed:
+			return amqp091.Delivery{}, false
+		case <-changed:
+		}
+	}
+}
+
+func (c *Client) takeUnackedLocked(tag uint64) (*delivery, error) {
+	d, ok := c.unacked[tag]
+	if !ok {
+		return nil, fmt.Errorf("rabbitmqtest: unknown delivery tag %d", tag)
+	}
+	delete(c.unacked, tag)
+	return d, nil
+}
+
+// deadLetterLocked routes a rejected message through its queue's dead letter
+// exchange with the x-death entry the broker would add. Like the broker, it
+// drops the message when no binding matches.
+func (c *Client) deadLetterLocked(d *delivery) {
+	exchange, key := c.config.DeadLetterExchange, c.config.DeadLetterQueue
+	if args := c.queueArgs[d.queue]; args != nil {
+		if ex, ok := args["x-dead-letter-exchange"].(string); ok {
+			exchange, key = ex, d.queue
+		}
+		if k, ok := args["x-dead-letter-routing-key"].(string); ok {
+			key = k
+		}
+	}
+
+	msg := d.msg
+	headers := amqp091.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers["x-death"] = []interface{}{amqp091.Table{
+		"queue":        d.queue,
+		"reason":       "rejected",
+		"count":        int64(1),
+		"exchange":     "",
+		"routing-keys": []interface{}{d.queue},
+		"time":         time.Now(),
+	}}
+	msg.Headers = headers
+
+	queues, err := c.routeLocked(exchange, key, msg.Headers)
+	if err != nil {
+		return
+	}
+	for _, queue := range queues {
+		if queue == c.config.DeadLetterQueue {
+			c.dead = append(c.dead, Publication{Queue: queue, Exchange: exchange, RoutingKey: key, Publishing: msg})
+		}
+		c.enqueueLocked(&delivery{queue: queue, msg: msg})
+	}
+}
+
+func (c *Client) settleLocked(queue string) {
+	c.settled[queue]++
+	c.notifyLocked()
+}
+
+func (c *Client) takeFailureLocked(queue string) error {
+	pending := c.failures[queue]
+	if len(pending) == 0 {
+		return nil
+	}
+	c.failures[queue] = pending[1:]
+	return pending[0]
+}
+
+func (c *Client) enqueueLocked(d *delivery) {
+	c.queues[d.queue] = append(c.queues[d.queue], d)
+	c.notifyLocked()
+}
+
+// notifyLocked wakes every waiter; callers must hold c.mu
+func (c *Client) notifyLocked() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+func toDelivery(ack amqp091.Acknowledger, tag uint64, d *delivery) amqp091.Delivery {
+	return amqp091.Delivery{
+		Acknowledger:    ack,
+		Headers:         d.msg.Headers,
+		ContentType:     d.msg.ContentType,
+		ContentEncoding: d.msg.ContentEncoding,
+		DeliveryMode:    d.msg.DeliveryMode,
+		Priority:        d.msg.Priority,
+		CorrelationId:   d.msg.CorrelationId,
+		ReplyTo:         d.msg.ReplyTo,
+		Expiration:      d.msg.Expiration,
+		MessageId:       d.msg.MessageId,
+		Timestamp:       d.msg.Timestamp,
+		Type:            d.msg.Type,
+		UserId:          d.msg.UserId,
+		AppId:           d.msg.AppId,
+		DeliveryTag:     tag,
+		Redelivered:     d.count > 1,
+		RoutingKey:      d.queue,
+		Body:            d.msg.Body,
+	}
+}
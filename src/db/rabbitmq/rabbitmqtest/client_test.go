@@ -0,0 +1,69 @@
+package rabbitmqtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Ajinx1/go-storage-config/src/db/rabbitmq"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+type order struct {
+	ID string `json:"id"`
+}
+
+func TestUndecodableMessageSkipsRetries(t *testing.T) {
+	fake := NewClient(rabbitmq.RabbitMQConfig{MaxHandlerRetries: 3})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	fake.Inject("orders", amqp091.Publishing{Body: []byte("{not json")})
+	consumer, err := fake.ConsumeWithMiddleware(ctx, "orders", func(context.Context, interface{}) error {
+		t.Error("handler ran for an undecodable message")
+		return nil
+	}, &order{})
+	if err != nil {
+		t.Fatalf("consume failed: %v", err)
+	}
+	if err := fake.WaitConsumed(ctx, "orders", 1); err != nil {
+		t.Fatalf("message was not consumed: %v", err)
+	}
+	consumer.Stop(ctx)
+
+	if n := len(fake.Retries()); n != 0 {
+		t.Errorf("scheduled %d retries, want none", n)
+	}
+	if n := len(fake.DeadLetters()); n != 1 {
+		t.Errorf("dead-lettered %d messages, want 1", n)
+	}
+}
+
+func TestRejectUsesDeadLetterExchange(t *testing.T) {
+	fake := NewClient(rabbitmq.RabbitMQConfig{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	fake.Inject("orders", amqp091.Publishing{Body: []byte("{}")})
+	consumer, err := fake.ConsumeDeliveries(ctx, "orders", func(_ context.Context, d amqp091.Delivery) {
+		d.Nack(false, false)
+	})
+	if err != nil {
+		t.Fatalf("consume failed: %v", err)
+	}
+	if err := fake.WaitConsumed(ctx, "orders", 1); err != nil {
+		t.Fatalf("message was not consumed: %v", err)
+	}
+	consumer.Stop(ctx)
+
+	letters, err := fake.ListDeadLetters(ctx, rabbitmq.DeadLetterFilter{})
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(letters) != 1 {
+		t.Fatalf("listed %d dead letters, want 1", len(letters))
+	}
+	if dl := letters[0]; dl.OriginalQueue != "orders" || dl.Reason != "rejected" || dl.Count != 1 {
+		t.Errorf("dead letter parsed as %+v", dl)
+	}
+}
@@ -0,0 +1,45 @@
+package rabbitmqtest
+
+import (
+	"context"
+	"sync"
+)
+
+// consumer implements rabbitmq.Consumer for the fake client
+type consumer struct {
+	tag    string
+	cancel context.CancelFunc
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+	err    error // Written before done is closed
+}
+
+func (c *consumer) Tag() string {
+	return c.tag
+}
+
+func (c *consumer) Stop(ctx context.Context) error {
+	c.once.Do(func() { close(c.stop) })
+
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		c.cancel()
+		return ctx.Err()
+	}
+}
+
+func (c *consumer) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *consumer) Err() error {
+	select {
+	case <-c.done:
+		return c.err
+	default:
+		return nil
+	}
+}
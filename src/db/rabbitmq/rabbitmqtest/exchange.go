@@ -0,0 +1,187 @@
+package rabbitmqtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ajinx1/go-storage-config/src/db/rabbitmq"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// DeclareTopology records exchanges and bindings used to route PublishToExchange
+// and dead-lettering, and the queue arguments that pick a dead letter exchange
+func (c *Client) DeclareTopology(t rabbitmq.Topology) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, q := range t.Queues {
+		c.queueArgs[q.Name] = q.Args
+	}
+
+	for _, ex := range t.Exchanges {
+		if ex.Kind == "" {
+			ex.Kind = amqp091.ExchangeDirect
+		}
+		c.exchanges[ex.Name] = ex
+	}
+	for _, b := range t.Bindings {
+		if _, ok := c.exchanges[b.Exchange]; !ok {
+			return fmt.Errorf("rabbitmqtest: exchange %s not declared", b.Exchange)
+		}
+		if !c.hasBindingLocked(b) {
+			c.bindings = append(c.bindings, b)
+		}
+	}
+	return nil
+}
+
+// hasBindingLocked reports whether an identical binding was already declared
+func (c *Client) hasBindingLocked(b rabbitmq.BindingSpec) bool {
+	for _, existing := range c.bindings {
+		if existing.Queue == b.Queue && existing.Exchange == b.Exchange && existing.RoutingKey == b.RoutingKey &&
+			fmt.Sprint(existing.Args) == fmt.Sprint(b.Args) {
+			return true
+		}
+	}
+	return false
+}
+
+// PublishToExchange routes body to every queue bound to exchange that matches
+// routingKey, failing with rabbitmq.ErrUnroutable when none does
+func (c *Client) PublishToExchange(ctx context.Context, exchange, routingKey string, body interface{}, opts rabbitmq.PublishOptions) error {
+	msg := amqp091.Publishing{
+		Headers:       opts.Headers,
+		ContentType:   opts.ContentType,
+		Priority:      opts.Priority,
+		CorrelationId: opts.CorrelationID,
+		ReplyTo:       opts.ReplyTo,
+		MessageId:     opts.MessageID,
+		Type:          opts.Type,
+		DeliveryMode:  amqp091.Persistent,
+		Timestamp:     time.Now(),
+	}
+	if opts.Expiration > 0 {
+		msg.Expiration = strconv.FormatInt(opts.Expiration.Milliseconds(), 10)
+	}
+	if raw, ok := body.([]byte); ok {
+		msg.Body = raw
+	} else {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		msg.Body = data
+		if msg.ContentType == "" {
+			msg.ContentType = "application/json"
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.isClosed {
+		return ErrClosed
+	}
+
+	var lastErr error
+	for i := 0; i < c.config.MaxRetries; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if lastErr = c.takeFailureLocked(exchange); lastErr != nil {
+			continue
+		}
+
+		queues, err := c.routeLocked(exchange, routingKey, msg.Headers)
+		if err != nil {
+			return err
+		}
+		for _, queue := range queues {
+			c.published = append(c.published, Publication{Queue: queue, Exchange: exchange, RoutingKey: routingKey, Publishing: msg})
+			c.enqueueLocked(&delivery{queue: queue, msg: msg})
+		}
+		return nil
+	}
+	return lastErr
+}
+
+func (c *Client) routeLocked(exchange, routingKey string, headers amqp091.Table) ([]string, error) {
+	if exchange == "" {
+		return []string{routingKey}, nil
+	}
+
+	ex, ok := c.exchanges[exchange]
+	if !ok {
+		return nil, fmt.Errorf("rabbitmqtest: exchange %s not declared", exchange)
+	}
+
+	var queues []string
+	seen := make(map[string]bool)
+	for _, b := range c.bindings {
+		if b.Exchange != exchange || seen[b.Queue] {
+			continue
+		}
+		var match bool
+		switch ex.Kind {
+		case amqp091.ExchangeFanout:
+			match = true
+		case amqp091.ExchangeTopic:
+			match = matchTopic(strings.Split(b.RoutingKey, "."), strings.Split(routingKey, "."))
+		case amqp091.ExchangeHeaders:
+			match = matchHeaders(b.Args, headers)
+		default:
+			match = b.RoutingKey == routingKey
+		}
+		if match {
+			seen[b.Queue] = true
+			queues = append(queues, b.Queue)
+		}
+	}
+
+	if len(queues) == 0 {
+		return nil, fmt.Errorf("%w: no binding on %s matches %q", rabbitmq.ErrUnroutable, exchange, routingKey)
+	}
+	return queues, nil
+}
+
+// matchTopic applies AMQP topic matching: * is one word, # is zero or more
+func matchTopic(pattern, words []string) bool {
+	if len(pattern) == 0 {
+		return len(words) == 0
+	}
+	if pattern[0] == "#" {
+		for i := 0; i <= len(words); i++ {
+			if matchTopic(pattern[1:], words[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(words) == 0 || (pattern[0] != "*" && pattern[0] != words[0]) {
+		return false
+	}
+	return matchTopic(pattern[1:], words[1:])
+}
+
+// matchHeaders applies headers exchange matching with x-match all (default) or any
+func matchHeaders(args, headers amqp091.Table) bool {
+	matchAny := args["x-match"] == "any"
+	matched := 0
+	total := 0
+	for k, v := range args {
+		if strings.HasPrefix(k, "x-") {
+			continue
+		}
+		total++
+		if hv, ok := headers[k]; ok && fmt.Sprint(hv) == fmt.Sprint(v) {
+			if matchAny {
+				return true
+			}
+			matched++
+		}
+	}
+	return !matchAny && matched == total
+}
@@ -0,0 +1,90 @@
+package rabbitmqtest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Ajinx1/go-storage-config/src/db/rabbitmq"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// ListDeadLetters returns the ready messages in the dead letter queue that
+// match filter, leaving them in place
+func (c *Client) ListDeadLetters(ctx context.Context, filter rabbitmq.DeadLetterFilter) ([]rabbitmq.DeadLetter, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	letters, _, err := c.browseLocked(ctx, filter)
+	return letters, err
+}
+
+// RedriveDeadLetters moves the dead letters matching filter back to their
+// original queues without x-retry-count. The rate is ignored.
+func (c *Client) RedriveDeadLetters(ctx context.Context, filter rabbitmq.DeadLetterFilter, _ int) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	letters, picked, err := c.browseLocked(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	dlq := c.config.DeadLetterQueue
+	sent := 0
+	for i, dl := range letters {
+		if dl.OriginalQueue == "" {
+			return sent, fmt.Errorf("dead letter %s has no original queue", dl.ID)
+		}
+		if err := c.takeFailureLocked(dl.OriginalQueue); err != nil {
+			return sent, fmt.Errorf("failed to redrive dead letter %s: %w", dl.ID, err)
+		}
+
+		d := picked[i]
+		msg := d.msg
+		msg.Headers = amqp091.Table{}
+		for k, v := range d.msg.Headers {
+			if k != rabbitmq.HeaderRetryCount {
+				msg.Headers[k] = v
+			}
+		}
+		c.removeLocked(dlq, d)
+		c.published = append(c.published, Publication{Queue: dl.OriginalQueue, RoutingKey: dl.OriginalQueue, Publishing: msg})
+		c.enqueueLocked(&delivery{queue: dl.OriginalQueue, msg: msg})
+		sent++
+	}
+	return sent, nil
+}
+
+// browseLocked parses the ready dead letters matching filter. Delivery tags
+// count from 1 as on the fresh channel the real client browses with.
+func (c *Client) browseLocked(ctx context.Context, filter rabbitmq.DeadLetterFilter) ([]rabbitmq.DeadLetter, []*delivery, error) {
+	var (
+		letters []rabbitmq.DeadLetter
+		picked  []*delivery
+	)
+	for i, d := range c.queues[c.config.DeadLetterQueue] {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		dl := rabbitmq.ParseDeadLetter(toDelivery(c, uint64(i+1), d))
+		if !filter.Match(&dl) {
+			continue
+		}
+		letters = append(letters, dl)
+		picked = append(picked, d)
+		if filter.Limit > 0 && len(letters) >= filter.Limit {
+			break
+		}
+	}
+	return letters, picked, nil
+}
+
+func (c *Client) removeLocked(queue string, d *delivery) {
+	ready := c.queues[queue]
+	for i, r := range ready {
+		if r == d {
+			c.queues[queue] = append(ready[:i:i], ready[i+1:]...)
+			return
+		}
+	}
+}
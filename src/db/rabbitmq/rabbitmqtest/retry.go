@@ -0,0 +1,40 @@
+package rabbitmqtest
+
+import (
+	"context"
+	"time"
+
+	"github.com/Ajinx1/go-storage-config/src/db/rabbitmq"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// HandleFailure settles a failed delivery like the real client
+func (c *Client) HandleFailure(_ context.Context, queue string, d amqp091.Delivery, cause error, retryable bool) {
+	c.handleFailure(queue, d, cause, retryable)
+}
+
+// handleFailure mirrors the real client: the message is requeued immediately
+// with x-retry-count incremented, or dead-lettered with the failure headers
+// once MaxHandlerRetries is exhausted, and the delivery is acked
+func (c *Client) handleFailure(queue string, msg amqp091.Delivery, cause error, retryable bool) {
+	retries := rabbitmq.RetryCount(msg.Headers)
+
+	c.mu.Lock()
+	if retryable && retries < c.config.MaxHandlerRetries {
+		delay := c.config.RetryBackoffMs[min(retries, len(c.config.RetryBackoffMs)-1)]
+		retry := rabbitmq.Redeliverable(msg, rabbitmq.FailureHeaders(msg.Headers, queue, retries+1, cause))
+		c.retries = append(c.retries, Publication{
+			Queue:      rabbitmq.RetryQueueName(queue, time.Duration(delay)*time.Millisecond),
+			RoutingKey: queue,
+			Publishing: retry,
+		})
+		c.enqueueLocked(&delivery{queue: queue, msg: retry})
+	} else {
+		dead := rabbitmq.Redeliverable(msg, rabbitmq.FailureHeaders(msg.Headers, queue, retries, cause))
+		c.dead = append(c.dead, Publication{Queue: c.config.DeadLetterQueue, RoutingKey: c.config.DeadLetterQueue, Publishing: dead})
+		c.enqueueLocked(&delivery{queue: c.config.DeadLetterQueue, msg: dead})
+	}
+	c.mu.Unlock()
+
+	msg.Ack(false)
+}
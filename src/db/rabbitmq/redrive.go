@@ -0,0 +1,223 @@
+package rabbitmq
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// DeadLetter is a message browsed from the dead letter queue with its x-death metadata
+type DeadLetter struct {
+	ID            string // AMQP MessageId, or a hash of the content and x-death metadata when unset
+	OriginalQueue string
+	Reason        string // rejected, expired, maxlen or delivery_limit
+	Error         string // x-error header set by the consumer, when present
+	Count         int64
+	Timestamp     time.Time
+	ContentType   string
+	Body          []byte
+	Headers       amqp091.Table
+
+	delivery amqp091.Delivery
+}
+
+// DeadLetterFilter selects dead letters; zero fields match everything
+type DeadLetterFilter struct {
+	OriginalQueue string
+	Since         time.Time
+	Until         time.Time
+	IDs           []string
+	Limit         int
+}
+
+// Match reports whether dl passes the filter
+func (f DeadLetterFilter) Match(dl *DeadLetter) bool {
+	if f.OriginalQueue != "" && dl.OriginalQueue != f.OriginalQueue {
+		return false
+	}
+	if !f.Since.IsZero() && dl.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && dl.Timestamp.After(f.Until) {
+		return false
+	}
+	if len(f.IDs) > 0 {
+		for _, id := range f.IDs {
+			if id == dl.ID {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// ListDeadLetters browses the dead letter queue and returns the messages
+// matching filter. Every message is returned to the queue afterwards.
+func (c *Client) ListDeadLetters(ctx context.Context, filter DeadLetterFilter) ([]DeadLetter, error) {
+	ch, err := c.openChannel()
+	if err != nil {
+		return nil, err
+	}
+	// Closing the channel requeues every browsed message
+	defer ch.Close()
+
+	return c.browseDeadLetters(ctx, ch, filter)
+}
+
+// RedriveDeadLetters republishes the dead letters matching filter to their
+// original queues, at most ratePerSecond per second (unlimited when zero),
+// and removes them from the dead letter queue. It returns how many were moved.
+func (c *Client) RedriveDeadLetters(ctx context.Context, filter DeadLetterFilter, ratePerSecond int) (int, error) {
+	ch, err := c.openChannel()
+	if err != nil {
+		return 0, err
+	}
+	defer ch.Close()
+
+	returns, err := confirmChannel(ch)
+	if err != nil {
+		return 0, err
+	}
+
+	letters, err := c.browseDeadLetters(ctx, ch, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	var tick <-chan time.Time
+	if ratePerSecond > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(ratePerSecond))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	sent := 0
+	for _, dl := range letters {
+		if dl.OriginalQueue == "" {
+			return sent, fmt.Errorf("dead letter %s has no original queue", dl.ID)
+		}
+		if tick != nil && sent > 0 {
+			select {
+			case <-ctx.Done():
+				return sent, ctx.Err()
+			case <-tick:
+			}
+		}
+
+		// Confirmed before the dead letter is acked, so a failed redrive never loses it
+		err := publishConfirmed(ctx, ch, returns, c.confirmTimeout(), "", dl.OriginalQueue, amqp091.Publishing{
+			Headers:       redriveHeaders(dl.Headers),
+			ContentType:   dl.ContentType,
+			DeliveryMode:  dl.delivery.DeliveryMode,
+			CorrelationId: dl.delivery.CorrelationId,
+			MessageId:     dl.delivery.MessageId,
+			Timestamp:     dl.delivery.Timestamp,
+			Type:          dl.delivery.Type,
+			Body:          dl.Body,
+		})
+		if err != nil {
+			return sent, fmt.Errorf("failed to redrive dead letter %s: %w", dl.ID, err)
+		}
+		if err := dl.delivery.Ack(false); err != nil {
+			return sent, fmt.Errorf("failed to remove dead letter %s: %w", dl.ID, err)
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// browseDeadLetters gets every message currently in the DLQ without acking it,
+// so none is delivered twice until ch is closed
+func (c *Client) browseDeadLetters(ctx context.Context, ch *amqp091.Channel, filter DeadLetterFilter) ([]DeadLetter, error) {
+	q, err := ch.QueueDeclarePassive(c.config.DeadLetterQueue, true, false, false, false, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect queue %s: %w", c.config.DeadLetterQueue, err)
+	}
+
+	var letters []DeadLetter
+	for i := 0; i < q.Messages; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		d, ok, err := ch.Get(c.config.DeadLetterQueue, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read queue %s: %w", c.config.DeadLetterQueue, err)
+		}
+		if !ok {
+			break
+		}
+
+		dl := ParseDeadLetter(d)
+		if filter.Match(&dl) {
+			letters = append(letters, dl)
+			if filter.Limit > 0 && len(letters) >= filter.Limit {
+				break
+			}
+		}
+	}
+	return letters, nil
+}
+
+// ParseDeadLetter reads the x-death entry and failure headers of a delivery from the DLQ
+func ParseDeadLetter(d amqp091.Delivery) DeadLetter {
+	dl := DeadLetter{
+		ID:          d.MessageId,
+		Timestamp:   d.Timestamp,
+		ContentType: d.ContentType,
+		Body:        d.Body,
+		Headers:     d.Headers,
+		delivery:    d,
+	}
+
+	if deaths, ok := d.Headers["x-death"].([]interface{}); ok && len(deaths) > 0 {
+		if death, ok := deaths[0].(amqp091.Table); ok {
+			dl.OriginalQueue, _ = death["queue"].(string)
+			dl.Reason, _ = death["reason"].(string)
+			dl.Count, _ = death["count"].(int64)
+			if t, ok := death["time"].(time.Time); ok {
+				dl.Timestamp = t
+			}
+		}
+	}
+	// Messages dead-lettered by ConsumeWithMiddleware carry their origin in headers
+	if queue, ok := d.Headers[HeaderOriginalQueue].(string); ok && queue != "" {
+		dl.OriginalQueue = queue
+		dl.Reason = "rejected"
+		if t, err := time.Parse(time.RFC3339, fmt.Sprint(d.Headers[HeaderFailedAt])); err == nil {
+			dl.Timestamp = t
+		}
+	}
+	if errMsg, ok := d.Headers[HeaderError].(string); ok {
+		dl.Error = errMsg
+	}
+	if dl.ID == "" {
+		dl.ID = contentID(&dl)
+	}
+	return dl
+}
+
+// contentID derives an ID for a dead letter without a MessageId from what
+// stays the same across reads, unlike the channel-local delivery tag
+func contentID(dl *DeadLetter) string {
+	h := sha256.New()
+	h.Write(dl.Body)
+	fmt.Fprintf(h, "\x00%s\x00%s\x00%s", dl.OriginalQueue, dl.Reason, dl.Timestamp.UTC().Format(time.RFC3339Nano))
+	return "sha256-" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// redriveHeaders resets the retry count so a redriven message gets a full set of retries
+func redriveHeaders(headers amqp091.Table) amqp091.Table {
+	out := amqp091.Table{}
+	for k, v := range headers {
+		if k != HeaderRetryCount {
+			out[k] = v
+		}
+	}
+	return out
+}
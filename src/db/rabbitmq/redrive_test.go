@@ -0,0 +1,47 @@
+package rabbitmq
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+func TestParseDeadLetterStableID(t *testing.T) {
+	deadAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	delivery := func(tag uint64, body string) amqp091.Delivery {
+		return amqp091.Delivery{
+			DeliveryTag: tag,
+			Body:        []byte(body),
+			Headers: amqp091.Table{
+				"x-death": []interface{}{amqp091.Table{
+					"queue":  "orders",
+					"reason": "rejected",
+					"count":  int64(1),
+					"time":   deadAt,
+				}},
+			},
+		}
+	}
+
+	first := ParseDeadLetter(delivery(1, `{"id":1}`))
+	again := ParseDeadLetter(delivery(7, `{"id":1}`))
+	other := ParseDeadLetter(delivery(1, `{"id":2}`))
+
+	if !strings.HasPrefix(first.ID, "sha256-") {
+		t.Errorf("ID = %q, want a content hash", first.ID)
+	}
+	if first.ID != again.ID {
+		t.Errorf("ID changed with the delivery tag: %q != %q", first.ID, again.ID)
+	}
+	if first.ID == other.ID {
+		t.Errorf("different bodies share ID %q", first.ID)
+	}
+
+	withID := delivery(1, `{"id":1}`)
+	withID.MessageId = "msg-1"
+	if got := ParseDeadLetter(withID).ID; got != "msg-1" {
+		t.Errorf("ID = %q, want the message ID", got)
+	}
+}
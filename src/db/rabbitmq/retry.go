@@ -0,0 +1,148 @@
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// Headers written on messages that failed in ConsumeWithMiddleware
+const (
+	HeaderRetryCount    = "x-retry-count"
+	HeaderError         = "x-error"
+	HeaderOriginalQueue = "x-original-queue"
+	HeaderFailedAt      = "x-failed-at"
+)
+
+// RetryQueueName is the delay queue for one backoff tier of queue
+func RetryQueueName(queue string, delay time.Duration) string {
+	return fmt.Sprintf("%s.retry.%dms", queue, delay.Milliseconds())
+}
+
+// retryDelay is the backoff tier for the given retry, repeating the last tier
+func (c *Client) retryDelay(retry int) time.Duration {
+	tiers := c.config.RetryBackoffMs
+	if retry >= len(tiers) {
+		retry = len(tiers) - 1
+	}
+	return time.Duration(tiers[retry]) * time.Millisecond
+}
+
+// RetryCount reads the x-retry-count header, zero when absent
+func RetryCount(headers amqp091.Table) int {
+	switch v := headers[HeaderRetryCount].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	return 0
+}
+
+// FailureHeaders returns the headers of a message that failed in queue after
+// retries. It copies headers without broker x-death history, which would
+// otherwise grow on every pass through a retry queue.
+func FailureHeaders(headers amqp091.Table, queue string, retries int, cause error) amqp091.Table {
+	out := amqp091.Table{}
+	for k, v := range headers {
+		if k != "x-death" && k != "x-first-death-exchange" && k != "x-first-death-queue" && k != "x-first-death-reason" &&
+			k != "x-last-death-exchange" && k != "x-last-death-queue" && k != "x-last-death-reason" {
+			out[k] = v
+		}
+	}
+	out[HeaderRetryCount] = int32(retries)
+	out[HeaderError] = cause.Error()
+	out[HeaderOriginalQueue] = queue
+	out[HeaderFailedAt] = time.Now().UTC().Format(time.RFC3339)
+	return out
+}
+
+// HandleFailure settles a delivery from ConsumeDeliveries that failed with
+// cause the way ConsumeWithMiddleware settles a failed message: a retryable
+// failure goes through the delay queues, anything else is dead-lettered.
+func (c *Client) HandleFailure(ctx context.Context, queue string, d amqp091.Delivery, cause error, retryable bool) {
+	c.handleFailure(ctx, queue, d, cause, retryable)
+}
+
+// handleFailure schedules a delayed retry of msg, or dead-letters it once
+// MaxHandlerRetries is exhausted, then acks the original delivery. When the
+// republish fails the delivery is requeued so the message is never lost.
+func (c *Client) handleFailure(ctx context.Context, queue string, msg amqp091.Delivery, cause error, retryable bool) {
+	retries := RetryCount(msg.Headers)
+
+	var err error
+	if retryable && retries < c.config.MaxHandlerRetries {
+		delay := c.retryDelay(retries)
+		err = c.scheduleRetry(ctx, queue, msg, delay, FailureHeaders(msg.Headers, queue, retries+1, cause))
+		if err == nil {
+			log.Printf("[Worker] Handler failed (attempt %d/%d), retrying in %v: %v", retries+1, c.config.MaxHandlerRetries+1, delay, cause)
+		}
+	} else {
+		err = c.deadLetterDelivery(ctx, msg, FailureHeaders(msg.Headers, queue, retries, cause))
+		if err == nil {
+			log.Printf("[Worker] Message dead-lettered after %d retries: %v", retries, cause)
+		}
+	}
+
+	if err != nil {
+		log.Printf("[Worker] Failed to reroute failed message, requeueing: %v", err)
+		msg.Nack(false, true)
+		return
+	}
+	if err := msg.Ack(false); err != nil {
+		log.Printf("[Worker] Failed to ack message: %v", err)
+	}
+}
+
+// scheduleRetry parks msg in the retry queue for delay. The per-message TTL
+// dead-letters it back to queue through the default exchange when it expires.
+func (c *Client) scheduleRetry(ctx context.Context, queue string, msg amqp091.Delivery, delay time.Duration, headers amqp091.Table) error {
+	retryQueue := RetryQueueName(queue, delay)
+	republish := Redeliverable(msg, headers)
+	republish.Expiration = strconv.FormatInt(delay.Milliseconds(), 10)
+
+	return c.withPublisher(ctx, func(pc *publishChannel) error {
+		_, err := pc.ch.QueueDeclare(retryQueue, true, false, false, false, amqp091.Table{
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": queue,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to declare retry queue %s: %w", retryQueue, err)
+		}
+		return publishConfirmed(ctx, pc.ch, pc.returns, c.confirmTimeout(), "", retryQueue, republish)
+	})
+}
+
+// deadLetterDelivery publishes msg straight to the dead letter queue with the failure headers
+func (c *Client) deadLetterDelivery(ctx context.Context, msg amqp091.Delivery, headers amqp091.Table) error {
+	return c.withPublisher(ctx, func(pc *publishChannel) error {
+		return publishConfirmed(ctx, pc.ch, pc.returns, c.confirmTimeout(), "", c.config.DeadLetterQueue, Redeliverable(msg, headers))
+	})
+}
+
+// Redeliverable copies msg into a persistent publishing with headers
+func Redeliverable(msg amqp091.Delivery, headers amqp091.Table) amqp091.Publishing {
+	return amqp091.Publishing{
+		Headers:         headers,
+		ContentType:     msg.ContentType,
+		ContentEncoding: msg.ContentEncoding,
+		DeliveryMode:    amqp091.Persistent,
+		Priority:        msg.Priority,
+		CorrelationId:   msg.CorrelationId,
+		ReplyTo:         msg.ReplyTo,
+		MessageId:       msg.MessageId,
+		Timestamp:       msg.Timestamp,
+		Type:            msg.Type,
+		AppId:           msg.AppId,
+		Body:            msg.Body,
+	}
+}
@@ -0,0 +1,201 @@
+package rabbitmq
+
+import (
+	"fmt"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// ExchangeSpec describes a durable exchange
+type ExchangeSpec struct {
+	Name       string
+	Kind       string // direct, fanout, topic or headers; defaults to direct
+	AutoDelete bool
+	Internal   bool
+	Args       amqp091.Table
+}
+
+// QueueSpec describes a durable queue. Unless Args sets x-dead-letter-exchange,
+// rejected messages go to the configured DeadLetterQueue through the
+// DeadLetterExchange like every other queue the client declares. Set x-max-priority in Args to honour message priorities.
+type QueueSpec struct {
+	Name       string
+	AutoDelete bool
+	Exclusive  bool
+	Args       amqp091.Table
+}
+
+// BindingSpec binds Queue to Exchange. RoutingKey is an exact key for direct
+// exchanges or a pattern (*, #) for topic exchanges; headers exchanges match on Args.
+type BindingSpec struct {
+	Queue      string
+	Exchange   string
+	RoutingKey string
+	Args       amqp091.Table
+}
+
+// key identifies a binding; the same queue, exchange, key and arguments bind once
+func (b BindingSpec) key() string {
+	return fmt.Sprintf("%s\x00%s\x00%s\x00%v", b.Queue, b.Exchange, b.RoutingKey, b.Args)
+}
+
+// Topology is declared when the client connects and again after every reconnect
+type Topology struct {
+	Exchanges []ExchangeSpec
+	Queues    []QueueSpec
+	Bindings  []BindingSpec
+}
+
+// merge returns a copy of t with other added. A redeclared exchange or queue
+// replaces the earlier spec of the same name and duplicate bindings are
+// dropped, so repeated declarations never grow the topology.
+func (t Topology) merge(other Topology) Topology {
+	out := Topology{
+		Exchanges: make([]ExchangeSpec, 0, len(t.Exchanges)+len(other.Exchanges)),
+		Queues:    make([]QueueSpec, 0, len(t.Queues)+len(other.Queues)),
+		Bindings:  make([]BindingSpec, 0, len(t.Bindings)+len(other.Bindings)),
+	}
+
+	exchanges := make(map[string]int)
+	for _, specs := range [][]ExchangeSpec{t.Exchanges, other.Exchanges} {
+		for _, ex := range specs {
+			if i, ok := exchanges[ex.Name]; ok {
+				out.Exchanges[i] = ex
+				continue
+			}
+			exchanges[ex.Name] = len(out.Exchanges)
+			out.Exchanges = append(out.Exchanges, ex)
+		}
+	}
+
+	queues := make(map[string]int)
+	for _, specs := range [][]QueueSpec{t.Queues, other.Queues} {
+		for _, q := range specs {
+			if i, ok := queues[q.Name]; ok {
+				out.Queues[i] = q
+				continue
+			}
+			queues[q.Name] = len(out.Queues)
+			out.Queues = append(out.Queues, q)
+		}
+	}
+
+	bindings := make(map[string]bool)
+	for _, specs := range [][]BindingSpec{t.Bindings, other.Bindings} {
+		for _, b := range specs {
+			if key := b.key(); !bindings[key] {
+				bindings[key] = true
+				out.Bindings = append(out.Bindings, b)
+			}
+		}
+	}
+	return out
+}
+
+// DeclareTopology declares t now and adds it to the topology restored on reconnect
+func (c *Client) DeclareTopology(t Topology) error {
+	// A failed declaration closes its channel, so each call gets a fresh one
+	ch, err := c.openChannel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	c.topologyMu.Lock()
+	defer c.topologyMu.Unlock()
+
+	if err := c.declareTopology(ch, t); err != nil {
+		return err
+	}
+	c.topology = c.topology.merge(t)
+	for _, q := range t.Queues {
+		c.queues[q.Name] = c.queueSpecArgs(q)
+	}
+	return nil
+}
+
+func (c *Client) DeclareExchange(spec ExchangeSpec) error {
+	return c.DeclareTopology(Topology{Exchanges: []ExchangeSpec{spec}})
+}
+
+func (c *Client) DeclareQueue(spec QueueSpec) error {
+	return c.DeclareTopology(Topology{Queues: []QueueSpec{spec}})
+}
+
+func (c *Client) BindQueue(spec BindingSpec) error {
+	return c.DeclareTopology(Topology{Bindings: []BindingSpec{spec}})
+}
+
+// setupTopology declares the dead letter exchange and every known exchange,
+// queue and binding on ch
+func (c *Client) setupTopology(ch *amqp091.Channel) error {
+	if c.passive {
+		return nil
+	}
+	if err := c.setupDLX(ch); err != nil {
+		return err
+	}
+
+	c.topologyMu.Lock()
+	defer c.topologyMu.Unlock()
+	return c.declareTopology(ch, c.topology)
+}
+
+func (c *Client) declareTopology(ch *amqp091.Channel, t Topology) error {
+	for _, ex := range t.Exchanges {
+		kind := ex.Kind
+		if kind == "" {
+			kind = amqp091.ExchangeDirect
+		}
+		if err := ch.ExchangeDeclare(ex.Name, kind, true, ex.AutoDelete, ex.Internal, false, ex.Args); err != nil {
+			return fmt.Errorf("failed to declare exchange %s: %w", ex.Name, err)
+		}
+	}
+
+	for _, q := range t.Queues {
+		if _, err := ch.QueueDeclare(q.Name, true, q.AutoDelete, q.Exclusive, false, c.queueSpecArgs(q)); err != nil {
+			return fmt.Errorf("failed to declare queue %s: %w", q.Name, err)
+		}
+	}
+
+	for _, b := range t.Bindings {
+		if err := ch.QueueBind(b.Queue, b.RoutingKey, b.Exchange, false, b.Args); err != nil {
+			return fmt.Errorf("failed to bind queue %s to %s: %w", b.Queue, b.Exchange, err)
+		}
+	}
+	return nil
+}
+
+// deadLetterArgs routes rejected messages to the dead letter queue. The DLX is
+// a direct exchange bound with the DLQ name, so the routing key must be set
+// or the message keeps its queue name as key and is dropped.
+func (c *Client) deadLetterArgs() amqp091.Table {
+	return amqp091.Table{
+		"x-dead-letter-exchange":    c.config.DeadLetterExchange,
+		"x-dead-letter-routing-key": c.config.DeadLetterQueue,
+	}
+}
+
+func (c *Client) queueSpecArgs(q QueueSpec) amqp091.Table {
+	args := c.deadLetterArgs()
+	if _, ok := q.Args["x-dead-letter-exchange"]; ok {
+		// A custom DLX routes with its own key, or the original one
+		delete(args, "x-dead-letter-routing-key")
+	}
+	for k, v := range q.Args {
+		args[k] = v
+	}
+	return args
+}
+
+// queueArgs returns the arguments queue was declared with, so implicit
+// redeclarations before publishing or consuming never conflict with the topology
+func (c *Client) queueArgs(queue string) amqp091.Table {
+	c.topologyMu.Lock()
+	defer c.topologyMu.Unlock()
+
+	if args, ok := c.queues[queue]; ok {
+		return args
+	}
+	return c.deadLetterArgs()
+}
@@ -43,3 +43,8 @@ func (c *Client) LPush(key string, value interface{}) error {
 func (c *Client) BRPop(timeout time.Duration, key string) ([]string, error) {
 	return c.conn.Client.BRPop(c.conn.Ctx, timeout, key).Result()
 }
+
+// SetNX sets key only when it does not exist and reports whether it was set
+func (c *Client) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	return c.conn.Client.SetNX(c.conn.Ctx, key, value, expiration).Result()
+}
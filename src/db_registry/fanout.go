@@ -0,0 +1,178 @@
+package db_registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FailurePolicy controls how a fan-out reacts to a failing tenant
+type FailurePolicy int
+
+const (
+	// BestEffort runs every tenant and reports all failures at the end
+	BestEffort FailurePolicy = iota
+	// FailFast cancels the remaining tenants after the first failure
+	FailFast
+)
+
+// TenantResult is the outcome of running a fan-out function against one datasource
+type TenantResult struct {
+	Source   ReportDataSource
+	Err      error
+	Skipped  bool
+	Duration time.Duration
+}
+
+// TenantValue is a TenantResult carrying the value returned for the datasource
+type TenantValue[T any] struct {
+	TenantResult
+	Value T
+}
+
+// ForEach runs fn against every active datasource with at most concurrency
+// calls in flight. The *gorm.DB handed to fn is bound to the fan-out context.
+// Results are ordered by datasource Code; the returned error joins every
+// tenant failure (BestEffort) or is the first failure (FailFast).
+func (r *Registry) ForEach(ctx context.Context, concurrency int,
+	fn func(src ReportDataSource, db *gorm.DB) error, policy ...FailurePolicy) ([]TenantResult, error) {
+
+	values, err := Collect(ctx, r, concurrency, func(src ReportDataSource, db *gorm.DB) (struct{}, error) {
+		return struct{}{}, fn(src, db)
+	}, policy...)
+
+	results := make([]TenantResult, len(values))
+	for i, v := range values {
+		results[i] = v.TenantResult
+	}
+	return results, err
+}
+
+// Collect is ForEach for functions that return a per-tenant value
+func Collect[T any](ctx context.Context, r *Registry, concurrency int,
+	fn func(src ReportDataSource, db *gorm.DB) (T, error), policy ...FailurePolicy) ([]TenantValue[T], error) {
+
+	mode := BestEffort
+	if len(policy) > 0 {
+		mode = policy[0]
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	sources := r.activeSources()
+	results := make([]TenantValue[T], len(sources))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	sem := make(chan struct{}, concurrency)
+
+	for i, src := range sources {
+		results[i].Source = src
+
+		if !acquire(ctx, sem) {
+			results[i].Skipped = true
+			results[i].Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func(res *TenantValue[T]) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			start := time.Now()
+			res.Value, res.Err = runTenant(ctx, r, res.Source, fn)
+			res.Duration = time.Since(start)
+
+			if res.Err != nil && mode == FailFast {
+				once.Do(func() {
+					firstErr = fmt.Errorf("datasource %s: %w", res.Source.Code, res.Err)
+					cancel()
+				})
+			}
+		}(&results[i])
+	}
+	wg.Wait()
+
+	if mode == FailFast {
+		if firstErr != nil {
+			return results, firstErr
+		}
+		// The caller's context ended before every tenant ran
+		for _, res := range results {
+			if res.Skipped {
+				return results, res.Err
+			}
+		}
+		return results, nil
+	}
+
+	var errs []error
+	for _, res := range results {
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("datasource %s: %w", res.Source.Code, res.Err))
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+// acquire takes a slot of sem unless ctx ends first. The context is checked
+// before and after, since select picks at random when both cases are ready.
+func acquire(ctx context.Context, sem chan struct{}) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case sem <- struct{}{}:
+	}
+	if ctx.Err() != nil {
+		<-sem
+		return false
+	}
+	return true
+}
+
+func runTenant[T any](ctx context.Context, r *Registry, src ReportDataSource,
+	fn func(ReportDataSource, *gorm.DB) (T, error)) (value T, err error) {
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+
+	db, err := r.Get(src.DatabaseName)
+	if err != nil {
+		return value, err
+	}
+	return fn(src, db.WithContext(ctx))
+}
+
+// activeSources returns a snapshot of the registered datasources ordered by Code
+func (r *Registry) activeSources() []ReportDataSource {
+	r.mu.RLock()
+	sources := make([]ReportDataSource, 0, len(r.sources))
+	for _, s := range r.sources {
+		sources = append(sources, s)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(sources, func(i, j int) bool {
+		return sources[i].Code < sources[j].Code
+	})
+	return sources
+}
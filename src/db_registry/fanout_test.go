@@ -0,0 +1,64 @@
+package db_registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestForEachFailFastSkipsRemainingTenants(t *testing.T) {
+	r := newTestRegistry(t)
+	for i := 0; i < 6; i++ {
+		src := &ReportDataSource{Code: fmt.Sprintf("t%d", i), Name: "tenant", DatabaseName: fmt.Sprintf("db%d", i)}
+		if err := r.CreateSource(src); err != nil {
+			t.Fatalf("CreateSource: %v", err)
+		}
+	}
+
+	boom := errors.New("boom")
+	var calls atomic.Int32
+	results, err := r.ForEach(context.Background(), 1, func(src ReportDataSource, _ *gorm.DB) error {
+		calls.Add(1)
+		if src.Code == "t0" {
+			return boom
+		}
+		return nil
+	}, FailFast)
+
+	if !errors.Is(err, boom) {
+		t.Fatalf("ForEach error = %v, want %v", err, boom)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("fn ran %d times, want 1", n)
+	}
+	for _, res := range results[1:] {
+		if !res.Skipped || !errors.Is(res.Err, context.Canceled) {
+			t.Errorf("tenant %s: Skipped=%v Err=%v, want skipped with context.Canceled", res.Source.Code, res.Skipped, res.Err)
+		}
+	}
+}
+
+func TestForEachFailFastCancelledContext(t *testing.T) {
+	r := newTestRegistry(t)
+	if err := r.CreateSource(&ReportDataSource{Code: "a", Name: "A", DatabaseName: "a"}); err != nil {
+		t.Fatalf("CreateSource: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err := r.ForEach(ctx, 1, func(ReportDataSource, *gorm.DB) error {
+		t.Error("fn ran with a cancelled context")
+		return nil
+	}, FailFast)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ForEach error = %v, want context.Canceled", err)
+	}
+	if len(results) != 1 || !results[0].Skipped {
+		t.Fatalf("results = %+v, want one skipped tenant", results)
+	}
+}
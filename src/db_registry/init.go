@@ -8,16 +8,15 @@ func Init(metaDB *gorm.DB, factory DBFactory) (*Registry, error) {
 		return nil, err
 	}
 
-	srcMap := make(map[string]ReportDataSource)
+	r := &Registry{
+		metaDB:   metaDB,
+		dbs:      make(map[string]*gorm.DB),
+		sources:  make(map[string]ReportDataSource),
+		dbCreate: factory,
+	}
 	for _, s := range sources {
-		if s.DatabaseName != "" {
-			srcMap[s.DatabaseName] = s
-		}
+		r.putSource(s)
 	}
 
-	return &Registry{
-		dbs:      make(map[string]*gorm.DB),
-		sources:  srcMap,
-		dbCreate: factory,
-	}, nil
+	return r, nil
 }
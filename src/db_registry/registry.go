@@ -2,15 +2,46 @@ package db_registry
 
 import (
 	"sync"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+// retiredConnGrace is how long a dropped datasource's connection stays open
+// for queries that obtained it from Get before the change
+const retiredConnGrace = time.Minute
+
 type DBFactory func(databaseName string) (*gorm.DB, error)
 
 type Registry struct {
 	mu       sync.RWMutex
+	metaDB   *gorm.DB
 	dbs      map[string]*gorm.DB
 	sources  map[string]ReportDataSource
 	dbCreate DBFactory
 }
+
+// putSource registers an active datasource; callers must hold r.mu
+func (r *Registry) putSource(src ReportDataSource) {
+	if src.DatabaseName != "" {
+		r.sources[src.DatabaseName] = src
+	}
+}
+
+// dropSource forgets a datasource and closes its cached connection after
+// retiredConnGrace, so callers still holding it from Get can finish; callers must hold r.mu
+func (r *Registry) dropSource(databaseName string) {
+	delete(r.sources, databaseName)
+
+	db, ok := r.dbs[databaseName]
+	if !ok {
+		return
+	}
+	delete(r.dbs, databaseName)
+
+	time.AfterFunc(retiredConnGrace, func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+}
@@ -1,6 +1,24 @@
 package db_registry
 
-import "gorm.io/gorm"
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSourceNotFound    = errors.New("datasource not found")
+	ErrEmptyCode         = errors.New("datasource code must not be empty")
+	ErrEmptyDatabaseName = errors.New("datasource database name must not be empty")
+	ErrDuplicateCode     = errors.New("datasource code already exists")
+)
+
+// AutoMigrate creates or updates the ReportDataSource table
+func AutoMigrate(db *gorm.DB) error {
+	return db.AutoMigrate(&ReportDataSource{})
+}
 
 func loadActiveSources(db *gorm.DB) ([]ReportDataSource, error) {
 	var data []ReportDataSource
@@ -10,3 +28,112 @@ func loadActiveSources(db *gorm.DB) ([]ReportDataSource, error) {
 		Error
 	return data, err
 }
+
+func listSources(db *gorm.DB, activeOnly bool) ([]ReportDataSource, error) {
+	var data []ReportDataSource
+	query := db.Order("id")
+	if activeOnly {
+		query = query.Where("active = ?", true)
+	}
+	err := query.Find(&data).Error
+	return data, err
+}
+
+func findSource(db *gorm.DB, id uint) (ReportDataSource, error) {
+	var src ReportDataSource
+	err := db.First(&src, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return src, fmt.Errorf("%w: id %d", ErrSourceNotFound, id)
+	}
+	return src, err
+}
+
+func validateSource(db *gorm.DB, src *ReportDataSource) error {
+	src.Code = strings.TrimSpace(src.Code)
+	src.DatabaseName = strings.TrimSpace(src.DatabaseName)
+
+	if src.Code == "" {
+		return ErrEmptyCode
+	}
+	if src.DatabaseName == "" {
+		return ErrEmptyDatabaseName
+	}
+
+	var count int64
+	err := db.Model(&ReportDataSource{}).
+		Where("code = ? AND id <> ?", src.Code, src.ID).
+		Count(&count).
+		Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %s", ErrDuplicateCode, src.Code)
+	}
+	return nil
+}
+
+// codeConflict turns a unique-index violation on code into ErrDuplicateCode.
+// validateSource only gives a friendly early error; the index is what stops
+// two concurrent writers from both storing the same code.
+func codeConflict(db *gorm.DB, src *ReportDataSource, err error) error {
+	dup := errors.Is(err, gorm.ErrDuplicatedKey)
+	if t, ok := db.Dialector.(gorm.ErrorTranslator); ok && !dup {
+		dup = errors.Is(t.Translate(err), gorm.ErrDuplicatedKey)
+	}
+	if dup {
+		return fmt.Errorf("%w: %s", ErrDuplicateCode, src.Code)
+	}
+	return err
+}
+
+// createSource inserts a new datasource; new sources always start active
+func createSource(db *gorm.DB, src *ReportDataSource) error {
+	src.ID = 0
+	src.Active = true
+	if err := validateSource(db, src); err != nil {
+		return err
+	}
+	if err := db.Create(src).Error; err != nil {
+		return codeConflict(db, src, err)
+	}
+	return nil
+}
+
+// updateSource saves the descriptive fields of an existing datasource.
+// The active flag is left untouched; use setSourceActive for that.
+func updateSource(db *gorm.DB, src *ReportDataSource) (ReportDataSource, error) {
+	old, err := findSource(db, src.ID)
+	if err != nil {
+		return old, err
+	}
+	if err := validateSource(db, src); err != nil {
+		return old, err
+	}
+
+	err = db.Model(&ReportDataSource{ID: src.ID}).
+		Select("Code", "Name", "DatabaseName", "SchemaName").
+		Updates(src).
+		Error
+	if err != nil {
+		return old, codeConflict(db, src, err)
+	}
+	src.Active = old.Active
+	return old, nil
+}
+
+func setSourceActive(db *gorm.DB, id uint, active bool) (ReportDataSource, error) {
+	src, err := findSource(db, id)
+	if err != nil {
+		return src, err
+	}
+
+	err = db.Model(&ReportDataSource{ID: id}).
+		Update("active", active).
+		Error
+	if err != nil {
+		return src, err
+	}
+	src.Active = active
+	return src, nil
+}
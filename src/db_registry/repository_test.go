@@ -0,0 +1,95 @@
+package db_registry
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestRegistry returns a registry over an in-memory metadata database
+// whose datasources each open their own in-memory database
+func newTestRegistry(t *testing.T) *Registry {
+	t.Helper()
+
+	metaDB := openSQLite(t)
+	if err := AutoMigrate(metaDB); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+
+	r, err := Init(metaDB, func(string) (*gorm.DB, error) {
+		return openSQLite(t), nil
+	})
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	return r
+}
+
+func openSQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("sql.DB: %v", err)
+	}
+	// Every connection to :memory: is a separate database
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func TestCreateSourceConcurrentDuplicateCode(t *testing.T) {
+	r := newTestRegistry(t)
+
+	const writers = 8
+	errs := make([]error, writers)
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = r.CreateSource(&ReportDataSource{
+				Code:         "acme",
+				Name:         "Acme",
+				DatabaseName: fmt.Sprintf("acme_%d", i),
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	created := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, ErrDuplicateCode):
+			t.Errorf("CreateSource error = %v, want ErrDuplicateCode", err)
+		}
+	}
+	if created != 1 {
+		t.Errorf("created %d sources with the same code, want 1", created)
+	}
+}
+
+func TestCodeConflictMapsUniqueViolation(t *testing.T) {
+	r := newTestRegistry(t)
+
+	if err := r.CreateSource(&ReportDataSource{Code: "a", Name: "A", DatabaseName: "a"}); err != nil {
+		t.Fatalf("CreateSource: %v", err)
+	}
+	// Bypass validateSource to hit the unique index directly
+	dup := &ReportDataSource{Code: "a", Name: "A2", DatabaseName: "a2", Active: true}
+	err := codeConflict(r.metaDB, dup, r.metaDB.Create(dup).Error)
+	if !errors.Is(err, ErrDuplicateCode) {
+		t.Fatalf("codeConflict = %v, want ErrDuplicateCode", err)
+	}
+}
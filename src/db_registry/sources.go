@@ -0,0 +1,65 @@
+package db_registry
+
+// ListSources returns the datasources stored in the metadata database
+func (r *Registry) ListSources(activeOnly bool) ([]ReportDataSource, error) {
+	return listSources(r.metaDB, activeOnly)
+}
+
+// CreateSource stores a new active datasource and makes it available to Get
+func (r *Registry) CreateSource(src *ReportDataSource) error {
+	if err := createSource(r.metaDB, src); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.putSource(*src)
+	return nil
+}
+
+// UpdateSource saves Code, Name, DatabaseName and SchemaName of an existing
+// datasource. When DatabaseName changes, the cached connection for the old
+// database is retired and closed after a grace period.
+func (r *Registry) UpdateSource(src *ReportDataSource) error {
+	old, err := updateSource(r.metaDB, src)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if old.DatabaseName != src.DatabaseName || !src.Active {
+		r.dropSource(old.DatabaseName)
+	}
+	if src.Active {
+		r.putSource(*src)
+	}
+	return nil
+}
+
+// ActivateSource marks a datasource active and makes it available to Get
+func (r *Registry) ActivateSource(id uint) error {
+	src, err := setSourceActive(r.metaDB, id, true)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.putSource(src)
+	return nil
+}
+
+// DeactivateSource marks a datasource inactive so Get no longer returns it.
+// Its cached connection is closed after a grace period.
+func (r *Registry) DeactivateSource(id uint) error {
+	src, err := setSourceActive(r.metaDB, id, false)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dropSource(src.DatabaseName)
+	return nil
+}
@@ -0,0 +1,136 @@
+// Package dedup makes consumers idempotent: a message ID is claimed in a
+// Store before the handler runs, so redelivered copies are skipped within a
+// time window. Stores are backed by Redis (SET NX with TTL) or a SQL inbox table.
+package dedup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInProgress is returned by Do for a duplicate whose first copy is still
+// being processed, since that copy may yet fail and release its claim. The
+// middlewares never return it; they wait for the claim to resolve instead.
+var ErrInProgress = errors.New("message is already being processed")
+
+// ClaimState is the outcome of Store.Claim
+type ClaimState int
+
+const (
+	Claimed    ClaimState = iota // The caller won the key and must Complete or Release it
+	InProgress                   // Another consumer holds an unexpired claim
+	Done                         // The key was already processed
+)
+
+// Store records which message keys have been processed
+type Store interface {
+	// Claim marks key as in progress for lease when it is free, and otherwise
+	// reports whether it is still in progress or done
+	Claim(ctx context.Context, key string, lease time.Duration) (ClaimState, error)
+	// Complete marks key as processed for ttl
+	Complete(ctx context.Context, key string, ttl time.Duration) error
+	// Release forgets key so a redelivery can process it again
+	Release(ctx context.Context, key string) error
+}
+
+type Config struct {
+	TTL           time.Duration // How long a processed key suppresses duplicates
+	Lease         time.Duration // How long an in-progress claim suppresses duplicates
+	RetryInterval time.Duration // How often a waiting duplicate re-checks an in-progress claim
+	Prefix        string        // Namespace for keys, e.g. the consumer group
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		TTL:           24 * time.Hour,
+		Lease:         5 * time.Minute,
+		RetryInterval: time.Second,
+		Prefix:        "dedup:",
+	}
+}
+
+func LoadConfig(cfg Config) *Config {
+	defaultCfg := DefaultConfig()
+	if cfg.TTL > 0 {
+		defaultCfg.TTL = cfg.TTL
+	}
+	if cfg.Lease > 0 {
+		defaultCfg.Lease = cfg.Lease
+	}
+	if cfg.RetryInterval > 0 {
+		defaultCfg.RetryInterval = cfg.RetryInterval
+	}
+	if cfg.Prefix != "" {
+		defaultCfg.Prefix = cfg.Prefix
+	}
+	return defaultCfg
+}
+
+// Deduplicator runs handlers at most once per message ID within Config.TTL.
+// A handler error releases the claim so the message can be retried. A
+// duplicate of a processed message is skipped. One that arrives while the
+// first copy is still in progress fails with ErrInProgress from Do, and is
+// held by the middlewares until that claim is completed, released or expires.
+type Deduplicator struct {
+	store  Store
+	config *Config
+}
+
+func New(store Store, cfg Config) *Deduplicator {
+	return &Deduplicator{store: store, config: LoadConfig(cfg)}
+}
+
+// Do runs fn unless id was already claimed and reports whether fn ran. It
+// returns ErrInProgress when another claim on id is still in progress.
+// An empty id is never deduplicated.
+func (d *Deduplicator) Do(ctx context.Context, id string, fn func() error) (bool, error) {
+	return d.do(ctx, id, false, fn)
+}
+
+// do is Do; with wait set it waits out an in-progress claim instead of
+// returning ErrInProgress
+func (d *Deduplicator) do(ctx context.Context, id string, wait bool, fn func() error) (bool, error) {
+	if id == "" {
+		return true, fn()
+	}
+
+	key := d.config.Prefix + id
+	state, err := d.claim(ctx, key, wait)
+	if err != nil {
+		return false, err
+	}
+	switch state {
+	case Done:
+		return false, nil
+	case InProgress:
+		return false, ErrInProgress
+	}
+
+	if err := fn(); err != nil {
+		if rerr := d.store.Release(ctx, key); rerr != nil {
+			return true, fmt.Errorf("%w (release failed: %v)", err, rerr)
+		}
+		return true, err
+	}
+	return true, d.store.Complete(ctx, key, d.config.TTL)
+}
+
+// claim claims key. With wait set it re-checks an in-progress claim every
+// RetryInterval; that claim is completed, released or expired within Lease,
+// so only a cancelled ctx ends the wait early.
+func (d *Deduplicator) claim(ctx context.Context, key string, wait bool) (ClaimState, error) {
+	for {
+		state, err := d.store.Claim(ctx, key, d.config.Lease)
+		if err != nil || state != InProgress || !wait {
+			return state, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return InProgress, ctx.Err()
+		case <-time.After(d.config.RetryInterval):
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package dedup
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Ajinx1/go-storage-config/src/db/kafka"
+	"github.com/Ajinx1/go-storage-config/src/messaging"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// KafkaIDHeader is the header carrying the message ID, as written by the
+// messaging and outbox packages
+const KafkaIDHeader = "message-id"
+
+// KafkaMiddleware skips Kafka messages whose message-id header was already
+// processed. While another consumer is still processing one it waits, for
+// up to Config.Lease, rather than failing into the retry and dead-letter
+// path. Messages without the header are always handled.
+func (d *Deduplicator) KafkaMiddleware() kafka.ConsumerMiddleware {
+	return func(next kafka.Handler) kafka.Handler {
+		return func(ctx context.Context, msg *kafka.Message) error {
+			ran, err := d.do(ctx, string(msg.Header(KafkaIDHeader)), true, func() error {
+				return next(ctx, msg)
+			})
+			if !ran && err == nil {
+				log.Printf("[Dedup] Skipping duplicate message on %s[%d]@%d", msg.Topic, msg.Partition, msg.Offset)
+			}
+			return err
+		}
+	}
+}
+
+// BusMiddleware skips messaging.Message values whose ID was already processed
+// and, like KafkaMiddleware, waits while another consumer is still processing it
+func (d *Deduplicator) BusMiddleware() messaging.Middleware {
+	return func(next messaging.Handler) messaging.Handler {
+		return func(ctx context.Context, msg *messaging.Message) error {
+			ran, err := d.do(ctx, msg.ID, true, func() error {
+				return next(ctx, msg)
+			})
+			if !ran && err == nil {
+				log.Printf("[Dedup] Skipping duplicate message %s on %s", msg.ID, msg.Topic)
+			}
+			return err
+		}
+	}
+}
+
+// RabbitMQHandler wraps a rabbitmq.Client ConsumeDeliveries handler so that
+// deliveries whose MessageId was already processed are acked without running
+// handler, and deliveries still being processed elsewhere wait for that
+// claim, for up to Config.Lease, before either outcome applies. The
+// claim is completed when handler acks and released when it nacks or
+// rejects. Deliveries without a MessageId are always handled.
+func (d *Deduplicator) RabbitMQHandler(handler func(context.Context, amqp091.Delivery)) func(context.Context, amqp091.Delivery) {
+	return func(ctx context.Context, msg amqp091.Delivery) {
+		if msg.MessageId == "" {
+			handler(ctx, msg)
+			return
+		}
+
+		key := d.config.Prefix + msg.MessageId
+		state, err := d.claim(ctx, key, true)
+		if err != nil {
+			// Store failure or shutdown; back off so the requeue does not spin
+			log.Printf("[Dedup] Failed to claim message %s: %v", msg.MessageId, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(d.config.RetryInterval):
+			}
+			msg.Nack(false, true)
+			return
+		}
+		if state == Done {
+			log.Printf("[Dedup] Skipping duplicate message %s", msg.MessageId)
+			msg.Ack(false)
+			return
+		}
+
+		msg.Acknowledger = &claimAcknowledger{
+			Acknowledger: msg.Acknowledger,
+			ctx:          ctx,
+			key:          key,
+			dedup:        d,
+		}
+		handler(ctx, msg)
+	}
+}
+
+// claimAcknowledger completes or releases the claim when the delivery is settled
+type claimAcknowledger struct {
+	amqp091.Acknowledger
+	ctx   context.Context
+	key   string
+	dedup *Deduplicator
+}
+
+func (a *claimAcknowledger) Ack(tag uint64, multiple bool) error {
+	if err := a.dedup.store.Complete(a.ctx, a.key, a.dedup.config.TTL); err != nil {
+		log.Printf("[Dedup] Failed to complete %s: %v", a.key, err)
+	}
+	return a.Acknowledger.Ack(tag, multiple)
+}
+
+func (a *claimAcknowledger) Nack(tag uint64, multiple, requeue bool) error {
+	a.release()
+	return a.Acknowledger.Nack(tag, multiple, requeue)
+}
+
+func (a *claimAcknowledger) Reject(tag uint64, requeue bool) error {
+	a.release()
+	return a.Acknowledger.Reject(tag, requeue)
+}
+
+func (a *claimAcknowledger) release() {
+	if err := a.dedup.store.Release(a.ctx, a.key); err != nil {
+		log.Printf("[Dedup] Failed to release %s: %v", a.key, err)
+	}
+}
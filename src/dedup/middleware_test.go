@@ -0,0 +1,159 @@
+package dedup
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/rabbitmq/amqp091-go"
+
+	"github.com/Ajinx1/go-storage-config/src/db/kafka"
+	"github.com/Ajinx1/go-storage-config/src/db/kafka/kafkatest"
+	"github.com/Ajinx1/go-storage-config/src/db/rabbitmq"
+	"github.com/Ajinx1/go-storage-config/src/db/rabbitmq/rabbitmqtest"
+	"github.com/Ajinx1/go-storage-config/src/messaging"
+)
+
+// counters records how often the wrapped handler ran and how often a
+// delivery of m-1 went through the middleware
+type counters struct {
+	ran, delivered atomic.Int32
+}
+
+// testConsumer starts consuming one copy of m-1 through a deduplicating
+// middleware and returns how many messages it dead-lettered
+type testConsumer struct {
+	name  string
+	start func(ctx context.Context, t *testing.T, d *Deduplicator, c *counters) func() int
+}
+
+func busConsumer(bus messaging.MessageBus, deadLetters func() int) func(context.Context, *testing.T, *Deduplicator, *counters) func() int {
+	return func(ctx context.Context, t *testing.T, d *Deduplicator, c *counters) func() int {
+		if err := bus.Publish(ctx, "orders", &messaging.Message{ID: "m-1", Body: []byte("{}")}); err != nil {
+			t.Fatalf("publish failed: %v", err)
+		}
+		count := func(next messaging.Handler) messaging.Handler {
+			return func(ctx context.Context, msg *messaging.Message) error {
+				defer c.delivered.Add(1)
+				return next(ctx, msg)
+			}
+		}
+		go bus.Subscribe(ctx, "orders", func(context.Context, *messaging.Message) error {
+			c.ran.Add(1)
+			return nil
+		}, count, d.BusMiddleware())
+		return deadLetters
+	}
+}
+
+var testConsumers = []testConsumer{
+	{name: "memory bus", start: func(ctx context.Context, t *testing.T, d *Deduplicator, c *counters) func() int {
+		bus := messaging.NewMemoryBus()
+		return busConsumer(bus, func() int { return len(bus.DeadLetters()) })(ctx, t, d, c)
+	}},
+	{name: "rabbitmq bus", start: func(ctx context.Context, t *testing.T, d *Deduplicator, c *counters) func() int {
+		client := rabbitmqtest.NewClient(rabbitmq.RabbitMQConfig{MaxHandlerRetries: 2})
+		return busConsumer(messaging.NewRabbitMQBus(client), func() int { return len(client.DeadLetters()) })(ctx, t, d, c)
+	}},
+	{name: "kafka", start: func(ctx context.Context, _ *testing.T, d *Deduplicator, c *counters) func() int {
+		client := kafkatest.NewClient(kafka.KafkaConfig{ConsumerMaxRetries: 3})
+		client.Inject("orders", nil, []byte("{}"), sarama.RecordHeader{Key: []byte(KafkaIDHeader), Value: []byte("m-1")})
+		count := func(next kafka.Handler) kafka.Handler {
+			return func(ctx context.Context, msg *kafka.Message) error {
+				defer c.delivered.Add(1)
+				return next(ctx, msg)
+			}
+		}
+		go client.SubscribeMessages(ctx, []string{"orders"}, func(context.Context, *kafka.Message) error {
+			c.ran.Add(1)
+			return nil
+		}, count, d.KafkaMiddleware())
+		return func() int { return len(client.DeadLetters()) }
+	}},
+	{name: "rabbitmq handler", start: func(ctx context.Context, t *testing.T, d *Deduplicator, c *counters) func() int {
+		client := rabbitmqtest.NewClient(rabbitmq.RabbitMQConfig{MaxHandlerRetries: 2})
+		client.Inject("orders", amqp091.Publishing{MessageId: "m-1", Body: []byte("{}")})
+		handler := d.RabbitMQHandler(func(_ context.Context, msg amqp091.Delivery) {
+			c.ran.Add(1)
+			msg.Ack(false)
+		})
+		if _, err := client.ConsumeDeliveries(ctx, "orders", func(ctx context.Context, msg amqp091.Delivery) {
+			defer c.delivered.Add(1)
+			handler(ctx, msg)
+		}); err != nil {
+			t.Fatalf("consume failed: %v", err)
+		}
+		return func() int { return len(client.DeadLetters()) }
+	}},
+}
+
+func TestInProgressDuplicateWaits(t *testing.T) {
+	tests := []struct {
+		name    string
+		settle  func(ctx context.Context, store Store) error // How the first copy finishes
+		wantRan int32
+	}{
+		{
+			name:    "skipped once the first copy completes",
+			settle:  func(ctx context.Context, store Store) error { return store.Complete(ctx, "dedup:m-1", time.Hour) },
+			wantRan: 0,
+		},
+		{
+			name:    "handled once the first copy releases",
+			settle:  func(ctx context.Context, store Store) error { return store.Release(ctx, "dedup:m-1") },
+			wantRan: 1,
+		},
+	}
+
+	for _, tc := range testConsumers {
+		for _, tt := range tests {
+			t.Run(tc.name+"/"+tt.name, func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+
+				// Another consumer is still processing the first copy of m-1
+				store := openSQLStore(t)
+				if state, err := store.Claim(ctx, "dedup:m-1", time.Hour); err != nil || state != Claimed {
+					t.Fatalf("claim = %d, %v", state, err)
+				}
+				d := New(store, Config{Lease: time.Hour, RetryInterval: 5 * time.Millisecond})
+
+				var c counters
+				deadLetters := tc.start(ctx, t, d, &c)
+
+				time.Sleep(50 * time.Millisecond)
+				if got := c.delivered.Load(); got != 0 {
+					t.Fatalf("duplicate settled %d times while the first copy is in progress", got)
+				}
+				if got := deadLetters(); got != 0 {
+					t.Fatalf("dead-lettered %d messages while the first copy is in progress", got)
+				}
+
+				if err := tt.settle(ctx, store); err != nil {
+					t.Fatalf("settle first copy: %v", err)
+				}
+				deadline := time.Now().Add(time.Second)
+				for c.delivered.Load() == 0 {
+					if time.Now().After(deadline) {
+						t.Fatal("duplicate not settled after the first copy finished")
+					}
+					time.Sleep(5 * time.Millisecond)
+				}
+				// Give a wrongly scheduled redelivery the chance to show up
+				time.Sleep(50 * time.Millisecond)
+
+				if got := c.delivered.Load(); got != 1 {
+					t.Errorf("duplicate delivered %d times, want 1", got)
+				}
+				if got := c.ran.Load(); got != tt.wantRan {
+					t.Errorf("handler ran %d times, want %d", got, tt.wantRan)
+				}
+				if got := deadLetters(); got != 0 {
+					t.Errorf("dead-lettered %d messages, want none", got)
+				}
+			})
+		}
+	}
+}
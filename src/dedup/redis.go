@@ -0,0 +1,65 @@
+package dedup
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Ajinx1/go-storage-config/src/db/redis"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const (
+	stateProcessing = "processing"
+	stateDone       = "done"
+)
+
+// RedisCommands is the part of *redis.Client that RedisStore uses
+type RedisCommands interface {
+	SetNX(key string, value interface{}, expiration time.Duration) (bool, error)
+	Get(key string) (string, error)
+	Set(key string, value interface{}, expiration time.Duration) error
+	Del(keys ...string) error
+}
+
+var _ RedisCommands = (*redis.Client)(nil)
+
+// RedisStore keeps claims as Redis keys that expire on their own
+type RedisStore struct {
+	client RedisCommands
+}
+
+func NewRedisStore(client RedisCommands) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Claim(_ context.Context, key string, lease time.Duration) (ClaimState, error) {
+	won, err := s.client.SetNX(key, stateProcessing, lease)
+	if err != nil {
+		return InProgress, err
+	}
+	if won {
+		return Claimed, nil
+	}
+
+	state, err := s.client.Get(key)
+	if errors.Is(err, goredis.Nil) {
+		// Released or expired since SetNX; the redelivery will claim it
+		return InProgress, nil
+	}
+	if err != nil {
+		return InProgress, err
+	}
+	if state == stateDone {
+		return Done, nil
+	}
+	return InProgress, nil
+}
+
+func (s *RedisStore) Complete(_ context.Context, key string, ttl time.Duration) error {
+	return s.client.Set(key, stateDone, ttl)
+}
+
+func (s *RedisStore) Release(_ context.Context, key string) error {
+	return s.client.Del(key)
+}
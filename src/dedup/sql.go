@@ -0,0 +1,98 @@
+package dedup
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// InboxEntry is a row of the inbox table
+type InboxEntry struct {
+	MessageKey string    `gorm:"primaryKey;size:255" json:"message_key"`
+	Status     string    `gorm:"size:16;not null" json:"status"`
+	ExpiresAt  time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (InboxEntry) TableName() string {
+	return "inbox"
+}
+
+// SQLStore keeps claims in the inbox table. Expired rows are taken over by the
+// next claim; Cleanup removes them in bulk.
+type SQLStore struct {
+	db *gorm.DB
+}
+
+func NewSQLStore(db *gorm.DB) *SQLStore {
+	return &SQLStore{db: db}
+}
+
+// AutoMigrate creates or updates the inbox table
+func (s *SQLStore) AutoMigrate() error {
+	return s.db.AutoMigrate(&InboxEntry{})
+}
+
+func (s *SQLStore) Claim(ctx context.Context, key string, lease time.Duration) (ClaimState, error) {
+	db := s.db.WithContext(ctx)
+	now := time.Now().UTC()
+
+	// Take over an expired entry first
+	res := db.Model(&InboxEntry{}).
+		Where("message_key = ? AND expires_at < ?", key, now).
+		Updates(map[string]interface{}{
+			"status":     stateProcessing,
+			"expires_at": now.Add(lease),
+		})
+	if res.Error != nil {
+		return InProgress, res.Error
+	}
+	if res.RowsAffected > 0 {
+		return Claimed, nil
+	}
+
+	res = db.Clauses(clause.OnConflict{DoNothing: true}).Create(&InboxEntry{
+		MessageKey: key,
+		Status:     stateProcessing,
+		ExpiresAt:  now.Add(lease),
+		CreatedAt:  now,
+	})
+	if res.Error != nil {
+		return InProgress, res.Error
+	}
+	if res.RowsAffected > 0 {
+		return Claimed, nil
+	}
+
+	var entry InboxEntry
+	res = db.Where("message_key = ?", key).Limit(1).Find(&entry)
+	if res.Error != nil {
+		return InProgress, res.Error
+	}
+	if res.RowsAffected > 0 && entry.Status == stateDone {
+		return Done, nil
+	}
+	// Still processing, or released since the insert; either way retry later
+	return InProgress, nil
+}
+
+func (s *SQLStore) Complete(ctx context.Context, key string, ttl time.Duration) error {
+	return s.db.WithContext(ctx).Model(&InboxEntry{}).
+		Where("message_key = ?", key).
+		Updates(map[string]interface{}{
+			"status":     stateDone,
+			"expires_at": time.Now().UTC().Add(ttl),
+		}).Error
+}
+
+func (s *SQLStore) Release(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Where("message_key = ?", key).Delete(&InboxEntry{}).Error
+}
+
+// Cleanup deletes expired entries and returns how many were removed
+func (s *SQLStore) Cleanup(ctx context.Context) (int64, error) {
+	res := s.db.WithContext(ctx).Where("expires_at < ?", time.Now().UTC()).Delete(&InboxEntry{})
+	return res.RowsAffected, res.Error
+}
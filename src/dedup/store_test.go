@@ -0,0 +1,185 @@
+package dedup
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openSQLStore(t *testing.T) *SQLStore {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("sql.DB: %v", err)
+	}
+	// Every connection to :memory: is a separate database
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	store := NewSQLStore(db)
+	if err := store.AutoMigrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return store
+}
+
+// fakeRedis implements RedisCommands over a map with per-key expiry
+type fakeRedis struct {
+	mu      sync.Mutex
+	values  map[string]string
+	expires map[string]time.Time
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{values: make(map[string]string), expires: make(map[string]time.Time)}
+}
+
+func (r *fakeRedis) liveLocked(key string) bool {
+	if exp, ok := r.expires[key]; ok && !time.Now().Before(exp) {
+		delete(r.values, key)
+		delete(r.expires, key)
+	}
+	_, ok := r.values[key]
+	return ok
+}
+
+func (r *fakeRedis) setLocked(key string, value interface{}, expiration time.Duration) {
+	r.values[key] = value.(string)
+	delete(r.expires, key)
+	if expiration > 0 {
+		r.expires[key] = time.Now().Add(expiration)
+	}
+}
+
+func (r *fakeRedis) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.liveLocked(key) {
+		return false, nil
+	}
+	r.setLocked(key, value, expiration)
+	return true, nil
+}
+
+func (r *fakeRedis) Get(key string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.liveLocked(key) {
+		return "", goredis.Nil
+	}
+	return r.values[key], nil
+}
+
+func (r *fakeRedis) Set(key string, value interface{}, expiration time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.setLocked(key, value, expiration)
+	return nil
+}
+
+func (r *fakeRedis) Del(keys ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, key := range keys {
+		delete(r.values, key)
+		delete(r.expires, key)
+	}
+	return nil
+}
+
+var testStores = []struct {
+	name string
+	new  func(t *testing.T) Store
+}{
+	{name: "sql", new: func(t *testing.T) Store { return openSQLStore(t) }},
+	{name: "redis", new: func(*testing.T) Store { return NewRedisStore(newFakeRedis()) }},
+}
+
+func TestStoreClaimStates(t *testing.T) {
+	type step struct {
+		action string // claim, complete or release
+		lease  time.Duration
+		want   ClaimState
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "first claim wins and a second is in progress",
+			steps: []step{
+				{action: "claim", lease: time.Hour, want: Claimed},
+				{action: "claim", lease: time.Hour, want: InProgress},
+			},
+		},
+		{
+			name: "completed key is done",
+			steps: []step{
+				{action: "claim", lease: time.Hour, want: Claimed},
+				{action: "complete"},
+				{action: "claim", lease: time.Hour, want: Done},
+			},
+		},
+		{
+			name: "released key can be claimed again",
+			steps: []step{
+				{action: "claim", lease: time.Hour, want: Claimed},
+				{action: "release"},
+				{action: "claim", lease: time.Hour, want: Claimed},
+			},
+		},
+		{
+			name: "expired lease can be taken over",
+			steps: []step{
+				{action: "claim", lease: 10 * time.Millisecond, want: Claimed},
+				{action: "wait"},
+				{action: "claim", lease: time.Hour, want: Claimed},
+				{action: "claim", lease: time.Hour, want: InProgress},
+			},
+		},
+	}
+
+	for _, ts := range testStores {
+		for _, tt := range tests {
+			t.Run(ts.name+"/"+tt.name, func(t *testing.T) {
+				store := ts.new(t)
+				ctx := context.Background()
+
+				for i, s := range tt.steps {
+					switch s.action {
+					case "claim":
+						got, err := store.Claim(ctx, "dedup:m-1", s.lease)
+						if err != nil {
+							t.Fatalf("step %d: claim failed: %v", i, err)
+						}
+						if got != s.want {
+							t.Fatalf("step %d: claim = %d, want %d", i, got, s.want)
+						}
+					case "complete":
+						if err := store.Complete(ctx, "dedup:m-1", time.Hour); err != nil {
+							t.Fatalf("step %d: complete failed: %v", i, err)
+						}
+					case "release":
+						if err := store.Release(ctx, "dedup:m-1"); err != nil {
+							t.Fatalf("step %d: release failed: %v", i, err)
+						}
+					case "wait":
+						time.Sleep(20 * time.Millisecond)
+					}
+				}
+			})
+		}
+	}
+}
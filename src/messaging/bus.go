@@ -0,0 +1,96 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrBusClosed = errors.New("message bus is closed")
+
+// MessageBus is the broker-neutral publish/subscribe API implemented for Kafka,
+// RabbitMQ and in memory. A topic is a Kafka topic or a RabbitMQ queue.
+type MessageBus interface {
+	Publish(ctx context.Context, topic string, msg *Message) error
+	// Subscribe handles messages from topic until ctx is cancelled
+	Subscribe(ctx context.Context, topic string, handler Handler, middlewares ...Middleware) error
+	Close() error
+}
+
+// Handler processes a message. A message the handler did not settle itself is
+// acked when the handler returns nil and nacked with requeue otherwise, so a
+// returned error is retried before the message is dead-lettered.
+type Handler func(ctx context.Context, msg *Message) error
+
+// Middleware wraps a Handler; the first middleware passed to Subscribe runs first
+type Middleware func(next Handler) Handler
+
+type Message struct {
+	ID        string
+	Topic     string
+	Key       []byte
+	Body      []byte
+	Headers   map[string]string
+	Timestamp time.Time
+
+	mu      sync.Mutex
+	settled bool
+	settle  func(ack, requeue bool, cause error) error
+}
+
+// ErrNacked is the failure recorded for a message nacked by its handler
+var ErrNacked = errors.New("message nacked by handler")
+
+// Ack marks the message as processed. Settling a message twice is a no-op.
+func (m *Message) Ack() error {
+	return m.finish(true, false, nil)
+}
+
+// Nack reports that the message failed, with the same outcome on every bus.
+// With requeue it is retried under the backend's retry policy (Kafka consumer
+// retries, RabbitMQ delayed retry queues, MemoryBus redeliveries) and
+// dead-lettered once the retries run out. Without requeue it is dead-lettered
+// at once.
+func (m *Message) Nack(requeue bool) error {
+	return m.finish(false, requeue, ErrNacked)
+}
+
+func (m *Message) finish(ack, requeue bool, cause error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.settled || m.settle == nil {
+		return nil
+	}
+	m.settled = true
+	return m.settle(ack, requeue, cause)
+}
+
+func (m *Message) isSettled() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.settled
+}
+
+// Chain applies middlewares to h so that middlewares[0] runs first
+func Chain(h Handler, middlewares ...Middleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// handle runs handler and settles msg from its result when the handler did not
+func handle(ctx context.Context, handler Handler, msg *Message) error {
+	err := handler(ctx, msg)
+	if !msg.isSettled() {
+		if err == nil {
+			return msg.Ack()
+		}
+		if nackErr := msg.finish(false, true, err); nackErr != nil {
+			return nackErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,190 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Ajinx1/go-storage-config/src/db/kafka"
+	"github.com/Ajinx1/go-storage-config/src/db/kafka/kafkatest"
+	"github.com/Ajinx1/go-storage-config/src/db/rabbitmq"
+	"github.com/Ajinx1/go-storage-config/src/db/rabbitmq/rabbitmqtest"
+)
+
+// testBus is a MessageBus with a way to count its dead letters. Every bus
+// allows three deliveries of a failing message.
+type testBus struct {
+	name string
+	new  func() (MessageBus, func() int)
+}
+
+var testBuses = []testBus{
+	{name: "memory", new: func() (MessageBus, func() int) {
+		bus := NewMemoryBus()
+		bus.MaxRedeliveries = 2
+		return bus, func() int { return len(bus.DeadLetters()) }
+	}},
+	{name: "kafka", new: func() (MessageBus, func() int) {
+		client := kafkatest.NewClient(kafka.KafkaConfig{ConsumerMaxRetries: 3})
+		return NewKafkaBus(client), func() int { return len(client.DeadLetters()) }
+	}},
+	{name: "rabbitmq", new: func() (MessageBus, func() int) {
+		client := rabbitmqtest.NewClient(rabbitmq.RabbitMQConfig{MaxHandlerRetries: 2})
+		return NewRabbitMQBus(client), func() int { return len(client.DeadLetters()) }
+	}},
+}
+
+func TestSettlementContract(t *testing.T) {
+	errHandler := errors.New("handler failed")
+
+	tests := []struct {
+		name         string
+		handler      func(msg *Message) error
+		wantAttempts int32
+		wantDead     int
+	}{
+		{
+			name:         "nil is acked",
+			handler:      func(*Message) error { return nil },
+			wantAttempts: 1,
+		},
+		{
+			name:         "error is retried then dead-lettered",
+			handler:      func(*Message) error { return errHandler },
+			wantAttempts: 3,
+			wantDead:     1,
+		},
+		{
+			name:         "nack with requeue is retried then dead-lettered",
+			handler:      func(msg *Message) error { return msg.Nack(true) },
+			wantAttempts: 3,
+			wantDead:     1,
+		},
+		{
+			name:         "nack without requeue is dead-lettered at once",
+			handler:      func(msg *Message) error { return msg.Nack(false) },
+			wantAttempts: 1,
+			wantDead:     1,
+		},
+		{
+			name: "explicit ack wins over a returned error",
+			handler: func(msg *Message) error {
+				msg.Ack()
+				return errHandler
+			},
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tb := range testBuses {
+		for _, tt := range tests {
+			t.Run(tb.name+"/"+tt.name, func(t *testing.T) {
+				bus, deadLetters := tb.new()
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+
+				if err := bus.Publish(ctx, "orders", &Message{ID: "m-1", Body: []byte("{}")}); err != nil {
+					t.Fatalf("publish failed: %v", err)
+				}
+
+				var attempts atomic.Int32
+				done := make(chan struct{})
+				subCtx, stop := context.WithCancel(ctx)
+				go func() {
+					defer close(done)
+					bus.Subscribe(subCtx, "orders", func(_ context.Context, msg *Message) error {
+						attempts.Add(1)
+						return tt.handler(msg)
+					})
+				}()
+
+				waitFor(t, func() bool {
+					return attempts.Load() >= tt.wantAttempts && deadLetters() >= tt.wantDead
+				})
+				// Give a wrongly scheduled redelivery the chance to show up
+				time.Sleep(50 * time.Millisecond)
+				stop()
+				<-done
+
+				if got := attempts.Load(); got != tt.wantAttempts {
+					t.Errorf("handler ran %d times, want %d", got, tt.wantAttempts)
+				}
+				if got := deadLetters(); got != tt.wantDead {
+					t.Errorf("dead-lettered %d messages, want %d", got, tt.wantDead)
+				}
+			})
+		}
+	}
+}
+
+func TestHandleSettlesOnce(t *testing.T) {
+	type settlement struct {
+		ack, requeue bool
+		cause        error
+	}
+	errHandler := errors.New("handler failed")
+
+	tests := []struct {
+		name    string
+		handler Handler
+		want    []settlement
+		wantErr error
+	}{
+		{
+			name:    "nil acks",
+			handler: func(context.Context, *Message) error { return nil },
+			want:    []settlement{{ack: true}},
+		},
+		{
+			name:    "error nacks with requeue and the cause",
+			handler: func(context.Context, *Message) error { return errHandler },
+			want:    []settlement{{requeue: true, cause: errHandler}},
+			wantErr: errHandler,
+		},
+		{
+			name: "handler settlement is kept",
+			handler: func(_ context.Context, msg *Message) error {
+				msg.Nack(false)
+				msg.Ack()
+				return nil
+			},
+			want: []settlement{{cause: ErrNacked}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []settlement
+			msg := &Message{settle: func(ack, requeue bool, cause error) error {
+				got = append(got, settlement{ack, requeue, cause})
+				return nil
+			}}
+
+			if err := handle(context.Background(), tt.handler, msg); !errors.Is(err, tt.wantErr) {
+				t.Errorf("handle returned %v, want %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("settled %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("settled %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// waitFor polls cond until it holds or a second has passed
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
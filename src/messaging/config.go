@@ -0,0 +1,42 @@
+package messaging
+
+import (
+	"fmt"
+
+	"github.com/Ajinx1/go-storage-config/src/db/kafka"
+	"github.com/Ajinx1/go-storage-config/src/db/rabbitmq"
+)
+
+const (
+	Kafka    = "kafka"
+	RabbitMQ = "rabbitmq"
+	Memory   = "memory"
+)
+
+type Config struct {
+	Backend  string // kafka, rabbitmq or memory
+	Kafka    kafka.KafkaConfig
+	RabbitMQ rabbitmq.RabbitMQConfig
+}
+
+// New connects the backend selected by cfg.Backend
+func New(cfg Config) (MessageBus, error) {
+	switch cfg.Backend {
+	case Kafka:
+		client, err := kafka.NewClientWithConfig(cfg.Kafka)
+		if err != nil {
+			return nil, err
+		}
+		return NewKafkaBus(client), nil
+	case RabbitMQ:
+		client, err := rabbitmq.NewClientWithConfig(cfg.RabbitMQ)
+		if err != nil {
+			return nil, err
+		}
+		return NewRabbitMQBus(client), nil
+	case Memory:
+		return NewMemoryBus(), nil
+	default:
+		return nil, fmt.Errorf("unsupported messaging backend: %s", cfg.Backend)
+	}
+}
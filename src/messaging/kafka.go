@@ -0,0 +1,80 @@
+package messaging
+
+import (
+	"context"
+
+	"github.com/Ajinx1/go-storage-config/src/db/kafka"
+	"github.com/IBM/sarama"
+)
+
+const messageIDHeader = "message-id"
+
+// KafkaBus adapts a kafka.API client to MessageBus. Messages nacked with
+// requeue go through the client's consumer retries and then its DLQ; without
+// requeue they are sent to the DLQ directly.
+type KafkaBus struct {
+	client kafka.API
+}
+
+func NewKafkaBus(client kafka.API) *KafkaBus {
+	return &KafkaBus{client: client}
+}
+
+func (b *KafkaBus) Publish(ctx context.Context, topic string, msg *Message) error {
+	var headers []sarama.RecordHeader
+	if msg.ID != "" {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(messageIDHeader), Value: []byte(msg.ID)})
+	}
+	for k, v := range msg.Headers {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(k), Value: []byte(v)})
+	}
+	return b.client.PublishWithHeaders(ctx, topic, msg.Key, msg.Body, headers)
+}
+
+func (b *KafkaBus) Subscribe(ctx context.Context, topic string, handler Handler, middlewares ...Middleware) error {
+	h := Chain(handler, middlewares...)
+
+	return b.client.SubscribeMessages(ctx, []string{topic}, func(ctx context.Context, km *kafka.Message) error {
+		// What the client sees for this delivery: nil settles it, an error retries it
+		var result error
+		msg := &Message{
+			Topic:     km.Topic,
+			Key:       km.Key,
+			Body:      km.Value,
+			Headers:   make(map[string]string, len(km.Headers)),
+			Timestamp: km.Timestamp,
+			settle: func(ack, requeue bool, cause error) error {
+				switch {
+				case ack:
+				case requeue:
+					result = cause
+					return nil
+				default:
+					if err := b.client.DeadLetter(ctx, km.Raw, 1, cause); err != nil {
+						result = err
+						return err
+					}
+				}
+				km.Ack()
+				return nil
+			},
+		}
+		for _, hdr := range km.Headers {
+			if hdr == nil {
+				continue
+			}
+			if string(hdr.Key) == messageIDHeader {
+				msg.ID = string(hdr.Value)
+				continue
+			}
+			msg.Headers[string(hdr.Key)] = string(hdr.Value)
+		}
+
+		handle(ctx, h, msg)
+		return result
+	})
+}
+
+func (b *KafkaBus) Close() error {
+	return b.client.Close()
+}
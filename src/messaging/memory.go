@@ -0,0 +1,199 @@
+package messaging
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryBus is an in-process MessageBus for unit tests. Subscribers of a topic
+// compete for its messages, messages published before anyone subscribes are
+// kept, and messages nacked with requeue are redelivered up to MaxRedeliveries
+// times before landing in DeadLetters; without requeue they land there at once.
+type MemoryBus struct {
+	MaxRedeliveries int
+
+	mu          sync.Mutex
+	topics      map[string]*memoryTopic
+	published   []*Message
+	deadLetters []*Message
+	done        chan struct{}
+	closed      bool
+}
+
+type memoryTopic struct {
+	queue  []*memoryEntry
+	notify chan struct{}
+}
+
+type memoryEntry struct {
+	msg      *Message
+	attempts int
+}
+
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{
+		MaxRedeliveries: 3,
+		topics:          make(map[string]*memoryTopic),
+		done:            make(chan struct{}),
+	}
+}
+
+func (b *MemoryBus) Publish(ctx context.Context, topic string, msg *Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrBusClosed
+	}
+
+	stored := copyMessage(msg)
+	stored.Topic = topic
+	if stored.Timestamp.IsZero() {
+		stored.Timestamp = time.Now()
+	}
+	b.published = append(b.published, stored)
+	b.enqueue(topic, &memoryEntry{msg: stored})
+	return nil
+}
+
+func (b *MemoryBus) Subscribe(ctx context.Context, topic string, handler Handler, middlewares ...Middleware) error {
+	h := Chain(handler, middlewares...)
+
+	for {
+		entry, notify, err := b.next(topic)
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-b.done:
+				return nil
+			case <-notify:
+			}
+			continue
+		}
+
+		b.deliver(ctx, topic, entry, h)
+	}
+}
+
+func (b *MemoryBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.closed {
+		b.closed = true
+		close(b.done)
+	}
+	return nil
+}
+
+// Published returns copies of every message published so far
+func (b *MemoryBus) Published() []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return snapshot(b.published)
+}
+
+// DeadLetters returns copies of the messages that were rejected for good
+func (b *MemoryBus) DeadLetters() []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return snapshot(b.deadLetters)
+}
+
+func (b *MemoryBus) deliver(ctx context.Context, topic string, entry *memoryEntry, h Handler) {
+	entry.attempts++
+	msg := copyMessage(entry.msg)
+	msg.settle = func(ack, requeue bool, _ error) error {
+		if ack {
+			return nil
+		}
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if requeue && entry.attempts <= b.MaxRedeliveries {
+			b.enqueue(topic, entry)
+		} else {
+			b.deadLetters = append(b.deadLetters, entry.msg)
+		}
+		return nil
+	}
+
+	handle(ctx, h, msg)
+}
+
+// next pops the oldest queued message of topic, or returns the channel signalled on the next publish
+func (b *MemoryBus) next(topic string) (*memoryEntry, <-chan struct{}, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, nil, ErrBusClosed
+	}
+
+	t := b.topic(topic)
+	if len(t.queue) == 0 {
+		return nil, t.notify, nil
+	}
+	entry := t.queue[0]
+	t.queue = t.queue[1:]
+	return entry, nil, nil
+}
+
+// enqueue appends entry to topic's queue; callers must hold b.mu
+func (b *MemoryBus) enqueue(topic string, entry *memoryEntry) {
+	t := b.topic(topic)
+	t.queue = append(t.queue, entry)
+	select {
+	case t.notify <- struct{}{}:
+	default:
+	}
+}
+
+// topic returns the named topic, creating it; callers must hold b.mu
+func (b *MemoryBus) topic(name string) *memoryTopic {
+	t, ok := b.topics[name]
+	if !ok {
+		t = &memoryTopic{notify: make(chan struct{}, 1)}
+		b.topics[name] = t
+	}
+	return t
+}
+
+func copyMessage(msg *Message) *Message {
+	headers := make(map[string]string, len(msg.Headers))
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	return &Message{
+		ID:        msg.ID,
+		Topic:     msg.Topic,
+		Key:       append([]byte(nil), msg.Key...),
+		Body:      append([]byte(nil), msg.Body...),
+		Headers:   headers,
+		Timestamp: msg.Timestamp,
+	}
+}
+
+func snapshot(msgs []*Message) []Message {
+	out := make([]Message, len(msgs))
+	for i, m := range msgs {
+		c := copyMessage(m)
+		out[i] = Message{
+			ID:        c.ID,
+			Topic:     c.Topic,
+			Key:       c.Key,
+			Body:      c.Body,
+			Headers:   c.Headers,
+			Timestamp: c.Timestamp,
+		}
+	}
+	return out
+}
@@ -0,0 +1,93 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Ajinx1/go-storage-config/src/db/rabbitmq"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+const messageKeyHeader = "message-key"
+
+// RabbitMQBus adapts a rabbitmq.API client to MessageBus. Topics map to
+// queues. Messages nacked with requeue go through the client's delayed retry
+// queues and then its dead letter queue; without requeue they are sent to the
+// dead letter queue directly.
+type RabbitMQBus struct {
+	client rabbitmq.API
+}
+
+func NewRabbitMQBus(client rabbitmq.API) *RabbitMQBus {
+	return &RabbitMQBus{client: client}
+}
+
+func (b *RabbitMQBus) Publish(ctx context.Context, topic string, msg *Message) error {
+	headers := amqp091.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	if len(msg.Key) > 0 {
+		headers[messageKeyHeader] = msg.Key
+	}
+
+	timestamp := msg.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	return b.client.PublishRaw(ctx, topic, amqp091.Publishing{
+		Headers:      headers,
+		MessageId:    msg.ID,
+		Timestamp:    timestamp,
+		DeliveryMode: amqp091.Persistent,
+		Body:         msg.Body,
+	})
+}
+
+func (b *RabbitMQBus) Subscribe(ctx context.Context, topic string, handler Handler, middlewares ...Middleware) error {
+	h := Chain(handler, middlewares...)
+
+	consumer, err := b.client.ConsumeDeliveries(ctx, topic, func(ctx context.Context, d amqp091.Delivery) {
+		msg := &Message{
+			ID:        d.MessageId,
+			Topic:     topic,
+			Body:      d.Body,
+			Headers:   make(map[string]string, len(d.Headers)),
+			Timestamp: d.Timestamp,
+			settle: func(ack, requeue bool, cause error) error {
+				if ack {
+					return d.Ack(false)
+				}
+				b.client.HandleFailure(ctx, topic, d, cause, requeue)
+				return nil
+			},
+		}
+		for k, v := range d.Headers {
+			if k == messageKeyHeader {
+				if key, ok := v.([]byte); ok {
+					msg.Key = key
+				}
+				continue
+			}
+			msg.Headers[k] = fmt.Sprint(v)
+		}
+
+		handle(ctx, h, msg)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Cancelling ctx stops the consumer, which drains in-flight messages
+	<-consumer.Done()
+	if err := consumer.Err(); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+func (b *RabbitMQBus) Close() error {
+	return b.client.Close()
+}
@@ -0,0 +1,63 @@
+package outbox
+
+import "time"
+
+type RelayConfig struct {
+	PollInterval    time.Duration // Wait between polls when the outbox is drained
+	BatchSize       int           // Rows claimed per poll
+	ClaimTimeout    time.Duration // How long claimed rows are hidden from other relays while they are published
+	MaxAttempts     int           // Publish attempts before a row is marked failed
+	RetryBackoff    time.Duration // Delay after the first failed attempt, doubled per attempt
+	MaxBackoff      time.Duration // Upper bound for the retry delay
+	Retention       time.Duration // How long delivered rows are kept; negative deletes them on delivery
+	CleanupInterval time.Duration // How often delivered rows past Retention are deleted
+	// FailedBlocksKey keeps later rows of an aggregate key waiting behind a
+	// failed row until it is requeued with Relay.Requeue. Otherwise a failed
+	// row is skipped and the key's later rows are published without it.
+	FailedBlocksKey bool
+}
+
+func DefaultRelayConfig() *RelayConfig {
+	return &RelayConfig{
+		PollInterval:    time.Second,
+		BatchSize:       100,
+		ClaimTimeout:    time.Minute,
+		MaxAttempts:     10,
+		RetryBackoff:    time.Second,
+		MaxBackoff:      5 * time.Minute,
+		Retention:       24 * time.Hour,
+		CleanupInterval: time.Minute,
+	}
+}
+
+func LoadRelayConfig(cfg RelayConfig) *RelayConfig {
+	defaultCfg := DefaultRelayConfig()
+	if cfg.PollInterval > 0 {
+		defaultCfg.PollInterval = cfg.PollInterval
+	}
+	if cfg.BatchSize > 0 {
+		defaultCfg.BatchSize = cfg.BatchSize
+	}
+	if cfg.ClaimTimeout > 0 {
+		defaultCfg.ClaimTimeout = cfg.ClaimTimeout
+	}
+	if cfg.MaxAttempts > 0 {
+		defaultCfg.MaxAttempts = cfg.MaxAttempts
+	}
+	if cfg.RetryBackoff > 0 {
+		defaultCfg.RetryBackoff = cfg.RetryBackoff
+	}
+	if cfg.MaxBackoff > 0 {
+		defaultCfg.MaxBackoff = cfg.MaxBackoff
+	}
+	if cfg.Retention != 0 {
+		defaultCfg.Retention = cfg.Retention
+	}
+	if cfg.CleanupInterval > 0 {
+		defaultCfg.CleanupInterval = cfg.CleanupInterval
+	}
+	if cfg.FailedBlocksKey {
+		defaultCfg.FailedBlocksKey = cfg.FailedBlocksKey
+	}
+	return defaultCfg
+}
@@ -0,0 +1,32 @@
+package outbox
+
+import "time"
+
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed" // Gave up after MaxAttempts; kept for inspection
+)
+
+// OutboxMessage is a row of the outbox table
+type OutboxMessage struct {
+	ID            uint64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	MessageID     string     `gorm:"size:64;uniqueIndex;not null" json:"message_id"`
+	AggregateType string     `gorm:"size:255" json:"aggregate_type"`
+	AggregateKey  string     `gorm:"size:255;index:idx_outbox_key_status" json:"aggregate_key"`
+	Topic         string     `gorm:"size:255;not null" json:"topic"`
+	Key           []byte     `json:"key"`
+	Payload       []byte     `gorm:"not null" json:"payload"`
+	ContentType   string     `gorm:"size:255" json:"content_type"`
+	Headers       string     `json:"headers"` // JSON object of string headers
+	Status        string     `gorm:"size:16;not null;index:idx_outbox_key_status;index:idx_outbox_status_available" json:"status"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	LastError     string     `json:"last_error"`
+	AvailableAt   time.Time  `gorm:"not null;index:idx_outbox_status_available" json:"available_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DeliveredAt   *time.Time `gorm:"index" json:"delivered_at"`
+}
+
+func (OutboxMessage) TableName() string {
+	return "outbox"
+}
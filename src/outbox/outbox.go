@@ -0,0 +1,117 @@
+// Package outbox implements the transactional outbox pattern: events are
+// written to an outbox table in the same gorm transaction as the business
+// data, and a Relay publishes them to Kafka or RabbitMQ afterwards.
+package outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrEmptyTopic   = errors.New("outbox message topic must not be empty")
+	ErrEmptyPayload = errors.New("outbox message payload must not be empty")
+)
+
+// Message is an event to be published once the surrounding transaction commits
+type Message struct {
+	ID            string // Defaults to a random UUID; published as the message ID
+	AggregateType string
+	AggregateKey  string // Messages sharing a key are published in insertion order
+	Topic         string // Kafka topic or RabbitMQ queue
+	Key           []byte // Kafka partition key; defaults to AggregateKey
+	Payload       []byte
+	ContentType   string
+	Headers       map[string]string
+}
+
+// AutoMigrate creates or updates the outbox table
+func AutoMigrate(db *gorm.DB) error {
+	return db.AutoMigrate(&OutboxMessage{})
+}
+
+// Enqueue stores msg in the outbox using tx, which should be the transaction
+// that writes the business data the event describes
+func Enqueue(tx *gorm.DB, msg Message) error {
+	row, err := newRow(msg)
+	if err != nil {
+		return err
+	}
+	return tx.Create(row).Error
+}
+
+// EnqueueJSON marshals v as JSON and stores it in the outbox using tx
+func EnqueueJSON(tx *gorm.DB, topic, aggregateKey string, v interface{}) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return Enqueue(tx, Message{
+		AggregateKey: aggregateKey,
+		Topic:        topic,
+		Payload:      payload,
+		ContentType:  "application/json",
+	})
+}
+
+func newRow(msg Message) (*OutboxMessage, error) {
+	if msg.Topic == "" {
+		return nil, ErrEmptyTopic
+	}
+	if len(msg.Payload) == 0 {
+		return nil, ErrEmptyPayload
+	}
+	if msg.ID == "" {
+		msg.ID = uuid.NewString()
+	}
+	if msg.Key == nil && msg.AggregateKey != "" {
+		msg.Key = []byte(msg.AggregateKey)
+	}
+
+	var headers string
+	if len(msg.Headers) > 0 {
+		data, err := json.Marshal(msg.Headers)
+		if err != nil {
+			return nil, err
+		}
+		headers = string(data)
+	}
+
+	now := time.Now().UTC()
+	return &OutboxMessage{
+		MessageID:     msg.ID,
+		AggregateType: msg.AggregateType,
+		AggregateKey:  msg.AggregateKey,
+		Topic:         msg.Topic,
+		Key:           msg.Key,
+		Payload:       msg.Payload,
+		ContentType:   msg.ContentType,
+		Headers:       headers,
+		Status:        StatusPending,
+		AvailableAt:   now,
+		CreatedAt:     now,
+	}, nil
+}
+
+// message converts a stored row back into the Message that was enqueued
+func (r *OutboxMessage) message() (Message, error) {
+	msg := Message{
+		ID:            r.MessageID,
+		AggregateType: r.AggregateType,
+		AggregateKey:  r.AggregateKey,
+		Topic:         r.Topic,
+		Key:           r.Key,
+		Payload:       r.Payload,
+		ContentType:   r.ContentType,
+	}
+	if r.Headers != "" {
+		if err := json.Unmarshal([]byte(r.Headers), &msg.Headers); err != nil {
+			return msg, err
+		}
+	}
+	return msg, nil
+}
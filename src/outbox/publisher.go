@@ -0,0 +1,56 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/Ajinx1/go-storage-config/src/db/kafka"
+	"github.com/Ajinx1/go-storage-config/src/db/rabbitmq"
+	"github.com/IBM/sarama"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// messageIDHeader carries Message.ID on Kafka, matching the messaging package
+const messageIDHeader = "message-id"
+
+// Publisher delivers relayed outbox messages to a broker
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// PublisherFunc adapts a function to Publisher
+type PublisherFunc func(ctx context.Context, msg Message) error
+
+func (f PublisherFunc) Publish(ctx context.Context, msg Message) error {
+	return f(ctx, msg)
+}
+
+// KafkaPublisher publishes to msg.Topic, with the message ID in a message-id
+// header. Each call is a single attempt with no DLQ fallback; the relay retries.
+func KafkaPublisher(client kafka.API) Publisher {
+	return PublisherFunc(func(ctx context.Context, msg Message) error {
+		headers := []sarama.RecordHeader{{Key: []byte(messageIDHeader), Value: []byte(msg.ID)}}
+		for k, v := range msg.Headers {
+			headers = append(headers, sarama.RecordHeader{Key: []byte(k), Value: []byte(v)})
+		}
+		return client.PublishOnce(ctx, msg.Topic, msg.Key, msg.Payload, headers)
+	})
+}
+
+// RabbitMQPublisher publishes to the msg.Topic queue with the AMQP MessageId
+// set. Each call is a single attempt; the relay retries.
+func RabbitMQPublisher(client rabbitmq.API) Publisher {
+	return PublisherFunc(func(ctx context.Context, msg Message) error {
+		headers := amqp091.Table{}
+		for k, v := range msg.Headers {
+			headers[k] = v
+		}
+		return client.PublishRawOnce(ctx, msg.Topic, amqp091.Publishing{
+			MessageId:   msg.ID,
+			ContentType: msg.ContentType,
+			Headers:     headers,
+			Body:        msg.Payload,
+			Timestamp:   time.Now(),
+		})
+	})
+}
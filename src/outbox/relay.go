@@ -0,0 +1,222 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Relay publishes pending outbox rows. Several relays may poll the same table:
+// rows are claimed under a row lock that the others skip (SKIP LOCKED on
+// postgres, READPAST on sqlserver) and pushed ClaimTimeout into the future, so
+// publishing happens outside any transaction. A relay that dies mid-batch
+// leaves its rows to be claimed again once ClaimTimeout passes. A row is only
+// claimed when no earlier pending row shares its aggregate key, so each key is
+// published in order, one message per poll; Run polls again at once while
+// rows are being delivered, so a backlog on one key still drains quickly.
+// Rows with an empty key are not ordered. Rows that exhaust MaxAttempts are
+// marked failed; by default they stop blocking their key, so its later rows
+// are published without them. Set FailedBlocksKey to hold the key instead.
+type Relay struct {
+	db        *gorm.DB
+	publisher Publisher
+	config    *RelayConfig
+}
+
+func NewRelay(db *gorm.DB, publisher Publisher, cfg RelayConfig) *Relay {
+	return &Relay{
+		db:        db,
+		publisher: publisher,
+		config:    LoadRelayConfig(cfg),
+	}
+}
+
+// Run relays until ctx is cancelled, deleting delivered rows past Retention
+func (r *Relay) Run(ctx context.Context) error {
+	poll := time.NewTimer(0)
+	defer poll.Stop()
+	cleanup := time.NewTicker(r.config.CleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-cleanup.C:
+			if _, err := r.Cleanup(ctx); err != nil {
+				log.Printf("[Outbox] Cleanup failed: %v", err)
+			}
+		case <-poll.C:
+			claimed, delivered, err := r.relay(ctx)
+			if err != nil {
+				log.Printf("[Outbox] Relay failed: %v", err)
+			}
+			// Poll again straight away while there is a backlog. A delivered
+			// row may have unblocked the next row of its key.
+			if err == nil && (delivered > 0 || claimed == r.config.BatchSize) {
+				poll.Reset(0)
+			} else {
+				poll.Reset(r.config.PollInterval)
+			}
+		}
+	}
+}
+
+// RelayOnce claims one batch of due rows in a short transaction, then
+// publishes them and records each outcome. It returns how many rows were claimed.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	claimed, _, err := r.relay(ctx)
+	return claimed, err
+}
+
+// relay is RelayOnce, also returning how many of the claimed rows were delivered
+func (r *Relay) relay(ctx context.Context) (int, int, error) {
+	var rows []OutboxMessage
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if rows, err = r.claim(tx); err != nil || len(rows) == 0 {
+			return err
+		}
+
+		ids := make([]uint64, len(rows))
+		for i, row := range rows {
+			ids[i] = row.ID
+		}
+		lease := time.Now().UTC().Add(r.config.ClaimTimeout)
+		if err := tx.Model(&OutboxMessage{}).Where("id IN ?", ids).Update("available_at", lease).Error; err != nil {
+			return fmt.Errorf("failed to claim outbox rows: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	db := r.db.WithContext(ctx)
+	delivered := 0
+	for i := range rows {
+		ok, err := r.deliver(ctx, db, &rows[i])
+		if err != nil {
+			return len(rows), delivered, err
+		}
+		if ok {
+			delivered++
+		}
+	}
+	return len(rows), delivered, nil
+}
+
+// Requeue makes failed rows pending again with a fresh set of attempts and
+// returns how many were requeued
+func (r *Relay) Requeue(ctx context.Context, messageIDs ...string) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&OutboxMessage{}).
+		Where("status = ? AND message_id IN ?", StatusFailed, messageIDs).
+		Updates(map[string]interface{}{
+			"status":       StatusPending,
+			"attempts":     0,
+			"available_at": time.Now().UTC(),
+		})
+	return res.RowsAffected, res.Error
+}
+
+// Cleanup deletes delivered rows older than Retention and returns how many were removed
+func (r *Relay) Cleanup(ctx context.Context) (int64, error) {
+	cutoff := time.Now().UTC().Add(-r.config.Retention)
+	if r.config.Retention < 0 {
+		cutoff = time.Now().UTC()
+	}
+
+	res := r.db.WithContext(ctx).
+		Where("status = ? AND delivered_at < ?", StatusDelivered, cutoff).
+		Delete(&OutboxMessage{})
+	return res.RowsAffected, res.Error
+}
+
+// claim selects and locks the next due rows, keeping per-key order
+func (r *Relay) claim(tx *gorm.DB) ([]OutboxMessage, error) {
+	const where = `o.status = ? AND o.available_at <= ?
+		AND (o.aggregate_key = '' OR NOT EXISTS (
+			SELECT 1 FROM outbox p
+			WHERE p.aggregate_key = o.aggregate_key AND p.status IN ? AND p.id < o.id))`
+
+	var query string
+	switch tx.Dialector.Name() {
+	case "sqlserver":
+		query = "SELECT TOP (?) o.* FROM outbox o WITH (UPDLOCK, ROWLOCK, READPAST) WHERE " + where + " ORDER BY o.id"
+	case "postgres", "mysql":
+		query = "SELECT o.* FROM outbox o WHERE " + where + " ORDER BY o.id LIMIT ? FOR UPDATE SKIP LOCKED"
+	default:
+		// No row locking; only safe with a single relay
+		query = "SELECT o.* FROM outbox o WHERE " + where + " ORDER BY o.id LIMIT ?"
+	}
+
+	blocking := []string{StatusPending}
+	if r.config.FailedBlocksKey {
+		blocking = append(blocking, StatusFailed)
+	}
+
+	now := time.Now().UTC()
+	args := []interface{}{StatusPending, now, blocking}
+	if tx.Dialector.Name() == "sqlserver" {
+		args = append([]interface{}{r.config.BatchSize}, args...)
+	} else {
+		args = append(args, r.config.BatchSize)
+	}
+
+	var rows []OutboxMessage
+	if err := tx.Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to claim outbox rows: %w", err)
+	}
+	return rows, nil
+}
+
+// deliver publishes one claimed row, records the result and reports whether
+// it was delivered; only database errors are returned, publish failures are
+// scheduled for retry
+func (r *Relay) deliver(ctx context.Context, db *gorm.DB, row *OutboxMessage) (bool, error) {
+	msg, err := row.message()
+	if err == nil {
+		err = r.publisher.Publish(ctx, msg)
+	}
+
+	now := time.Now().UTC()
+	if err == nil {
+		if r.config.Retention < 0 {
+			return true, db.Delete(&OutboxMessage{}, row.ID).Error
+		}
+		return true, db.Model(&OutboxMessage{}).Where("id = ?", row.ID).Updates(map[string]interface{}{
+			"status":       StatusDelivered,
+			"attempts":     row.Attempts + 1,
+			"last_error":   "",
+			"delivered_at": now,
+		}).Error
+	}
+
+	attempts := row.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts":     attempts,
+		"last_error":   err.Error(),
+		"available_at": now.Add(r.backoff(attempts)),
+	}
+	if attempts >= r.config.MaxAttempts {
+		updates["status"] = StatusFailed
+		log.Printf("[Outbox] Giving up on message %s after %d attempts: %v", row.MessageID, attempts, err)
+	} else {
+		log.Printf("[Outbox] Publish of message %s failed (attempt %d/%d): %v", row.MessageID, attempts, r.config.MaxAttempts, err)
+	}
+	return false, db.Model(&OutboxMessage{}).Where("id = ?", row.ID).Updates(updates).Error
+}
+
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := r.config.RetryBackoff
+	for i := 1; i < attempts && delay < r.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.config.MaxBackoff {
+		delay = r.config.MaxBackoff
+	}
+	return delay
+}
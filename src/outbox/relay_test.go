@@ -0,0 +1,232 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openSQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("sql.DB: %v", err)
+	}
+	// Every connection to :memory: is a separate database
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := AutoMigrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// recorder is a Publisher that records delivered message IDs and fails the
+// next attempts of the IDs in failures
+type recorder struct {
+	mu        sync.Mutex
+	failures  map[string]int
+	delivered []string
+}
+
+func (p *recorder) Publish(_ context.Context, msg Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failures[msg.ID] > 0 {
+		p.failures[msg.ID]--
+		return errors.New("broker unavailable")
+	}
+	p.delivered = append(p.delivered, msg.ID)
+	return nil
+}
+
+func (p *recorder) ids() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.delivered...)
+}
+
+func enqueue(t *testing.T, db *gorm.DB, key string, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		if err := Enqueue(db, Message{ID: id, AggregateKey: key, Topic: "orders", Payload: []byte(id)}); err != nil {
+			t.Fatalf("enqueue %s: %v", id, err)
+		}
+	}
+}
+
+func loadRow(t *testing.T, db *gorm.DB, id string) OutboxMessage {
+	t.Helper()
+	var row OutboxMessage
+	if err := db.Where("message_id = ?", id).First(&row).Error; err != nil {
+		t.Fatalf("load %s: %v", id, err)
+	}
+	return row
+}
+
+// relayUntil calls RelayOnce until n messages were delivered or a second passed
+func relayUntil(t *testing.T, r *Relay, p *recorder, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for len(p.ids()) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("delivered %v, want %d messages", p.ids(), n)
+		}
+		if _, err := r.RelayOnce(context.Background()); err != nil {
+			t.Fatalf("relay failed: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// assertOrder checks that want appears in this relative order in got
+func assertOrder(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	pos := make(map[string]int, len(got))
+	for i, id := range got {
+		pos[id] = i
+	}
+	for i := 1; i < len(want); i++ {
+		if pos[want[i-1]] > pos[want[i]] {
+			t.Errorf("delivered %v, want %v in order", got, want)
+			return
+		}
+	}
+}
+
+func TestRelayKeepsPerKeyOrder(t *testing.T) {
+	db := openSQLite(t)
+	p := &recorder{failures: map[string]int{"a2": 2}}
+	r := NewRelay(db, p, RelayConfig{RetryBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+
+	enqueue(t, db, "a", "a1", "a2", "a3")
+	enqueue(t, db, "b", "b1", "b2")
+	enqueue(t, db, "", "x1")
+
+	relayUntil(t, r, p, 6)
+
+	got := p.ids()
+	assertOrder(t, got, "a1", "a2", "a3")
+	assertOrder(t, got, "b1", "b2")
+	if row := loadRow(t, db, "a2"); row.Status != StatusDelivered || row.Attempts != 3 {
+		t.Errorf("a2 is %s after %d attempts, want delivered after 3", row.Status, row.Attempts)
+	}
+}
+
+func TestRelayBacksOffFailedRows(t *testing.T) {
+	db := openSQLite(t)
+	p := &recorder{failures: map[string]int{"a1": 10}}
+	r := NewRelay(db, p, RelayConfig{MaxAttempts: 3, RetryBackoff: time.Hour, MaxBackoff: 3 * time.Hour})
+	enqueue(t, db, "a", "a1", "a2")
+
+	before := time.Now().UTC()
+	if _, err := r.RelayOnce(context.Background()); err != nil {
+		t.Fatalf("relay failed: %v", err)
+	}
+	row := loadRow(t, db, "a1")
+	if row.Attempts != 1 || row.Status != StatusPending || row.LastError == "" {
+		t.Fatalf("a1 after one failure: %+v", row)
+	}
+	if wait := row.AvailableAt.Sub(before); wait < time.Hour || wait > time.Hour+time.Minute {
+		t.Errorf("a1 retries after %v, want RetryBackoff", wait)
+	}
+
+	// Neither a1, still backing off, nor a2, queued behind it, is due
+	claimed, err := r.RelayOnce(context.Background())
+	if err != nil || claimed != 0 {
+		t.Errorf("claimed %d rows (%v) while a1 backs off, want none", claimed, err)
+	}
+
+	for attempts, want := range []time.Duration{time.Hour, 2 * time.Hour, 3 * time.Hour, 3 * time.Hour} {
+		if got := r.backoff(attempts + 1); got != want {
+			t.Errorf("backoff after %d attempts = %v, want %v", attempts+1, got, want)
+		}
+	}
+}
+
+func TestRelayFailedRows(t *testing.T) {
+	tests := []struct {
+		name            string
+		failedBlocksKey bool
+		wantA2          string
+	}{
+		{name: "skipped by default", wantA2: StatusDelivered},
+		{name: "block their key", failedBlocksKey: true, wantA2: StatusPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openSQLite(t)
+			p := &recorder{failures: map[string]int{"a1": 1}}
+			r := NewRelay(db, p, RelayConfig{MaxAttempts: 1, FailedBlocksKey: tt.failedBlocksKey})
+			enqueue(t, db, "a", "a1", "a2")
+
+			for i := 0; i < 2; i++ {
+				if _, err := r.RelayOnce(context.Background()); err != nil {
+					t.Fatalf("relay failed: %v", err)
+				}
+			}
+			if row := loadRow(t, db, "a1"); row.Status != StatusFailed {
+				t.Fatalf("a1 is %s, want failed", row.Status)
+			}
+			if row := loadRow(t, db, "a2"); row.Status != tt.wantA2 {
+				t.Fatalf("a2 is %s, want %s", row.Status, tt.wantA2)
+			}
+
+			if n, err := r.Requeue(context.Background(), "a1"); err != nil || n != 1 {
+				t.Fatalf("requeued %d rows: %v", n, err)
+			}
+			relayUntil(t, r, p, 2)
+			if tt.failedBlocksKey {
+				assertOrder(t, p.ids(), "a1", "a2")
+			}
+		})
+	}
+}
+
+func TestRunDrainsSingleKeyBacklog(t *testing.T) {
+	db := openSQLite(t)
+	p := &recorder{}
+	// With one row per key per poll, only immediate re-polls can drain this in time
+	r := NewRelay(db, p, RelayConfig{PollInterval: time.Hour})
+
+	const n = 50
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("m%02d", i)
+	}
+	enqueue(t, db, "a", ids...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(p.ids()) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("delivered %d of %d messages", len(p.ids()), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	assertOrder(t, p.ids(), ids...)
+}